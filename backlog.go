@@ -0,0 +1,123 @@
+package main
+
+import (
+	"sort"
+	"time"
+)
+
+// maxUnanswered is the number of oldest unanswered items listed in the backlog
+const maxUnanswered = 10
+
+// ageBuckets are the upper bounds (in days) of the backlog age histogram
+var ageBuckets = []struct {
+	Label string
+	Days  int
+}{
+	{"0-7d", 7},
+	{"7-30d", 30},
+	{"30-90d", 90},
+	{"90d+", 0},
+}
+
+// AgeBucket counts the open PRs and Issues of an age range
+type AgeBucket struct {
+	Label  string
+	PRs    int
+	Issues int
+}
+
+// Backlog describes the PRs and Issues which are open at the end of a period
+type Backlog struct {
+	// StaleDays is the number of days without activity after which
+	// an open PR or Issue is considered stale
+	StaleDays         int
+	OpenPRsAtStart    int
+	OpenIssuesAtStart int
+	OpenPRsAtEnd      int
+	OpenIssuesAtEnd   int
+	// Ages is a histogram of the age of open PRs and Issues at the end
+	Ages []*AgeBucket
+	// Stale lists the open PRs and Issues without recent activity
+	Stale Items
+	// Unanswered lists the oldest open PRs and Issues without response
+	Unanswered Items
+}
+
+// openAt returns true if the Item was open at time t
+func (i *Item) openAt(t time.Time) bool {
+	return i.CreatedAt.Before(t) && (i.ClosedAt.IsZero() || i.ClosedAt.After(t))
+}
+
+// lastActivity returns the time of the last comment, or of the creation,
+// of the Item before time t
+func (i *Item) lastActivity(t time.Time) time.Time {
+	last := i.CreatedAt
+	for _, c := range i.Comments {
+		if c.CreatedAt.After(last) && c.CreatedAt.Before(t) {
+			last = c.CreatedAt
+		}
+	}
+	return last
+}
+
+// NewBacklog computes the backlog for a period. open must contain all
+// currently open PRs and Issues and items all PRs and Issues updated
+// since the start of the period. Items on both lists are only counted once.
+func NewBacklog(period *Period, staleDays int, open, items Items) *Backlog {
+	b := &Backlog{StaleDays: staleDays}
+	for _, ab := range ageBuckets {
+		b.Ages = append(b.Ages, &AgeBucket{Label: ab.Label})
+	}
+
+	seen := make(map[string]bool)
+	staleBefore := period.End.AddDate(0, 0, -staleDays)
+	for _, i := range append(open, items...) {
+		if seen[i.ID] {
+			continue
+		}
+		seen[i.ID] = true
+
+		if i.openAt(period.Start) {
+			if i.PR {
+				b.OpenPRsAtStart++
+			} else {
+				b.OpenIssuesAtStart++
+			}
+		}
+		if !i.openAt(period.End) {
+			continue
+		}
+		if i.PR {
+			b.OpenPRsAtEnd++
+		} else {
+			b.OpenIssuesAtEnd++
+		}
+
+		age := period.End.Sub(i.CreatedAt)
+		for n, ab := range ageBuckets {
+			if ab.Days == 0 || age < time.Duration(ab.Days)*24*time.Hour {
+				if i.PR {
+					b.Ages[n].PRs++
+				} else {
+					b.Ages[n].Issues++
+				}
+				break
+			}
+		}
+
+		if i.lastActivity(period.End).Before(staleBefore) {
+			b.Stale = append(b.Stale, i)
+		}
+		if t, ok := i.FirstResponse(); !ok || t.After(period.End) {
+			b.Unanswered = append(b.Unanswered, i)
+		}
+	}
+
+	sort.Slice(b.Unanswered, func(i, j int) bool {
+		return b.Unanswered[i].CreatedAt.Before(b.Unanswered[j].CreatedAt)
+	})
+	if len(b.Unanswered) > maxUnanswered {
+		b.Unanswered = b.Unanswered[:maxUnanswered]
+	}
+	return b
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// Ways to handle activity by bots and ignored users
+const (
+	BotsInclude  = "include"
+	BotsExclude  = "exclude"
+	BotsSeparate = "separate"
+)
+
+// UserFilter identifies bots and users which should not be treated
+// like human contributors
+type UserFilter struct {
+	ignore map[string]bool
+	bots   bool
+}
+
+// NewUserFilter creates a filter for the users on the ignore list and,
+// if bots is set, for bots
+func NewUserFilter(ignore []string, bots bool) *UserFilter {
+	uf := &UserFilter{ignore: make(map[string]bool), bots: bots}
+	for _, u := range ignore {
+		uf.ignore[u] = true
+	}
+	return uf
+}
+
+// Match returns true if the user is on the ignore list or a bot
+// matched by the filter
+func (uf *UserFilter) Match(u *User) bool {
+	if u == nil {
+		return false
+	}
+	if uf.ignore[u.ID] {
+		return true
+	}
+	return uf.bots && (u.Bot || strings.HasSuffix(u.ID, "[bot]"))
+}
+
+// Filter returns the Items without any activity of matching users.
+// Items created by them are dropped and their comments are removed from
+// the remaining Items. The Items passed in are not modified.
+func (uf *UserFilter) Filter(items Items) Items {
+	var ret Items
+	for _, i := range items {
+		if uf.Match(i.CreatedBy) {
+			continue
+		}
+		c := *i
+		c.Comments = []*Comment{}
+		for _, comment := range i.Comments {
+			if !uf.Match(comment.User) {
+				c.Comments = append(c.Comments, comment)
+			}
+		}
+		if uf.Match(c.MergedBy) {
+			c.MergedBy = nil
+		}
+		ret = append(ret, &c)
+	}
+	return ret
+}
+
+// BotActivity summarises the activity of a bot or ignored user in a period
+type BotActivity struct {
+	User *User
+	// Opened is the number of PRs and Issues opened
+	Opened   int
+	Comments int
+	Merged   int
+	// Items are all PRs and Issues the user was active on
+	Items Items
+}
+
+// NewBotActivity collects the activity of all matching users in the
+// period. The result is sorted by user.
+func (uf *UserFilter) NewBotActivity(period *Period, items Items) []*BotActivity {
+	activity := make(map[string]*BotActivity)
+	get := func(u *User) *BotActivity {
+		ba, ok := activity[u.ID]
+		if !ok {
+			ba = &BotActivity{User: u}
+			activity[u.ID] = ba
+		}
+		return ba
+	}
+	for _, i := range items {
+		active := make(map[*BotActivity]bool)
+		if uf.Match(i.CreatedBy) && period.Match(i.CreatedAt) {
+			ba := get(i.CreatedBy)
+			ba.Opened++
+			active[ba] = true
+		}
+		if uf.Match(i.MergedBy) && period.Match(i.MergedAt) {
+			ba := get(i.MergedBy)
+			ba.Merged++
+			active[ba] = true
+		}
+		for _, comment := range i.Comments {
+			if uf.Match(comment.User) && period.Match(comment.CreatedAt) {
+				ba := get(comment.User)
+				ba.Comments++
+				active[ba] = true
+			}
+		}
+		for ba := range active {
+			ba.Items = append(ba.Items, i)
+		}
+	}
+
+	ret := make([]*BotActivity, 0, len(activity))
+	for _, ba := range activity {
+		ret = append(ret, ba)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].User.ID < ret[j].User.ID })
+	return ret
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// botItems returns Items with activity of a bot, an ignored CI account
+// and a human in March 2018
+func botItems() Items {
+	a := &User{ID: "alice"}
+	bot := &User{ID: "dependabot[bot]"}
+	ci := &User{ID: "ci"}
+	at := func(s string) time.Time { return *ghTime(s + "T10:00:00Z") }
+
+	return Items{
+		{PR: true, ID: "o/r#1", Repo: "o/r", Number: 1, CreatedBy: bot, CreatedAt: at("2018-03-02"),
+			ClosedAt: at("2018-03-03"), Merged: true, MergedAt: at("2018-03-03"), MergedBy: ci,
+			Comments: []*Comment{{User: ci, CreatedAt: at("2018-03-02"), Kind: CommentIssue}}},
+		{PR: true, ID: "o/r#2", Repo: "o/r", Number: 2, CreatedBy: ci, CreatedAt: at("2018-03-04")},
+		{PR: true, ID: "o/r#3", Repo: "o/r", Number: 3, CreatedBy: a, CreatedAt: at("2018-03-05"),
+			Comments: []*Comment{
+				{User: bot, CreatedAt: at("2018-03-05"), Kind: CommentIssue},
+				{User: ci, CreatedAt: at("2018-03-06"), Kind: CommentIssue},
+				{User: a, CreatedAt: at("2018-03-07"), Kind: CommentIssue},
+			}},
+	}
+}
+
+func TestUserFilter(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		bots     bool
+		items    string
+		comments int
+		mergedBy bool
+	}{
+		// -ignore-users ci keeps the bot
+		{"ignored users", false, "o/r#1 o/r#3", 2, false},
+		// -ignore-users ci -bots exclude drops both
+		{"ignored users and bots", true, "o/r#3", 1, false},
+	} {
+		items := botItems()
+		got := NewUserFilter([]string{"ci"}, tc.bots).Filter(items)
+		if ids(got) != tc.items {
+			t.Errorf("%s: got %s, want %s", tc.name, ids(got), tc.items)
+			continue
+		}
+		comments := 0
+		for _, i := range got {
+			comments += len(i.Comments)
+			if i.MergedBy != nil {
+				t.Errorf("%s: %s is still merged by %s", tc.name, i.ID, i.MergedBy.ID)
+			}
+		}
+		if comments != tc.comments {
+			t.Errorf("%s: got %d comments, want %d", tc.name, comments, tc.comments)
+		}
+		if len(items[2].Comments) != 3 || items[0].MergedBy == nil {
+			t.Errorf("%s: the Items passed in were modified", tc.name)
+		}
+	}
+}
+
+func TestNewBotActivity(t *testing.T) {
+	p, _ := NewPeriodFromMonth("2018-03")
+	for _, tc := range []struct {
+		bots bool
+		want []BotActivity
+	}{
+		{false, []BotActivity{{User: &User{ID: "ci"}, Opened: 1, Comments: 2, Merged: 1}}},
+		{true, []BotActivity{
+			{User: &User{ID: "ci"}, Opened: 1, Comments: 2, Merged: 1},
+			{User: &User{ID: "dependabot[bot]"}, Opened: 1, Comments: 1},
+		}},
+	} {
+		got := NewUserFilter([]string{"ci"}, tc.bots).NewBotActivity(p, botItems())
+		if len(got) != len(tc.want) {
+			t.Errorf("bots %t: got %d users, want %d", tc.bots, len(got), len(tc.want))
+			continue
+		}
+		for n, ba := range got {
+			want := tc.want[n]
+			if ba.User.ID != want.User.ID || ba.Opened != want.Opened || ba.Comments != want.Comments || ba.Merged != want.Merged {
+				t.Errorf("bots %t: got %s %d/%d/%d, want %s %d/%d/%d", tc.bots,
+					ba.User.ID, ba.Opened, ba.Comments, ba.Merged, want.User.ID, want.Opened, want.Comments, want.Merged)
+			}
+		}
+	}
+	// ci opened o/r#2, merged o/r#1 and commented on o/r#1 and o/r#3
+	got := NewUserFilter([]string{"ci"}, false).NewBotActivity(p, botItems())
+	if items := ids(got[0].Items); items != "o/r#1 o/r#2 o/r#3" {
+		t.Errorf("got items %s of ci", items)
+	}
+}
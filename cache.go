@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// cacheVersion is incremented whenever the information stored for an
+// Item changes, so that older entries are no longer used.
+const cacheVersion = 11
+
+// Cache is a simple on-disk cache of Items. Each Item is stored as a
+// JSON file keyed by repository and number. A cached Item is only
+// used as long as its UpdatedAt matches the one reported by GitHub.
+// A nil *Cache is valid and never caches anything.
+type Cache struct {
+	Dir string
+}
+
+// NewCache creates a new cache in the given directory
+func NewCache(dir string) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &Cache{Dir: dir}, nil
+}
+
+// DefaultCacheDir returns the default location of the cache:
+// $XDG_CACHE_HOME or $HOME/.cache, falling back to the temporary
+// directory if neither is set
+func DefaultCacheDir() string {
+	dir := os.Getenv("XDG_CACHE_HOME")
+	if dir == "" {
+		if home := os.Getenv("HOME"); home != "" {
+			dir = filepath.Join(home, ".cache")
+		} else {
+			dir = os.TempDir()
+		}
+	}
+	return filepath.Join(dir, "gh-report")
+}
+
+func (c *Cache) path(repo string, number int) string {
+	return filepath.Join(c.Dir, fmt.Sprintf("v%d", cacheVersion), filepath.FromSlash(repo), fmt.Sprintf("%d.json", number))
+}
+
+// Get returns the cached Item of a repository if it was last updated
+// at updatedAt. All users of the Item are added to users. Returns nil
+// if no matching item is in the cache.
+func (c *Cache) Get(repo string, number int, updatedAt time.Time, users *Users) *Item {
+	if c == nil {
+		return nil
+	}
+	data, err := ioutil.ReadFile(c.path(repo, number))
+	if err != nil {
+		return nil
+	}
+	i := &Item{}
+	if err := json.Unmarshal(data, i); err != nil {
+		warnf("Ignoring corrupt cache entry for %s#%d: %v\n", repo, number, err)
+		return nil
+	}
+	if !i.UpdatedAt.Equal(updatedAt) {
+		debugf("  Cache: %s is stale\n", i.ID)
+		return nil
+	}
+	debugf("  Cache: using %s\n", i.ID)
+	i.shareUsers(users)
+	return i
+}
+
+// Put stores an Item in the cache
+func (c *Cache) Put(i *Item) error {
+	if c == nil {
+		return nil
+	}
+	data, err := json.Marshal(i)
+	if err != nil {
+		return err
+	}
+	p := c.path(i.Repo, i.Number)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	// Write to a temporary file first so an interrupted run does
+	// not leave a truncated entry behind.
+	tmp := p + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, p)
+}
+
+// Clear removes all entries from the cache
+func (c *Cache) Clear() error {
+	if c == nil {
+		return nil
+	}
+	entries, err := ioutil.ReadDir(c.Dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := os.RemoveAll(filepath.Join(c.Dir, e.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/github"
+)
+
+// changelogCategories are the categories of a changelog in the order
+// they are listed. A PR is put into the first category with a keyword
+// matching one of its labels. Only the part of a label after the last
+// "/" is matched, so "kind/bug" is a fix but "area/docker" is not
+// documentation. PRs without a match go into "Other".
+var changelogCategories = []struct {
+	Name     string
+	Keywords []string
+}{
+	{"Features", []string{"feature", "features", "enhancement", "enhancements"}},
+	{"Fixes", []string{"bug", "bugs", "bugfix", "fix", "fixes"}},
+	{"Documentation", []string{"doc", "docs", "documentation"}},
+}
+
+// changelogCategory returns the changelog category of a PR
+func changelogCategory(i *Item) string {
+	for _, c := range changelogCategories {
+		for _, l := range i.Labels {
+			l = strings.ToLower(l)
+			l = strings.TrimSpace(l[strings.LastIndex(l, "/")+1:])
+			for _, k := range c.Keywords {
+				if l == k {
+					return c.Name
+				}
+			}
+		}
+	}
+	return "Other"
+}
+
+// FirstContribution is the first merged PR of a new contributor
+type FirstContribution struct {
+	User *User
+	Item *Item
+}
+
+// Changelog lists the PRs merged between two tags of a repository
+type Changelog struct {
+	// BaseURL is the URL of the GitHub web interface
+	BaseURL string
+	Repo    string
+	From    string
+	To      string
+	// Categories holds the merged PRs by category
+	Categories []*ItemGroup
+	// Contributors are the authors of the merged PRs sorted by ID
+	Contributors []*User
+	// FirstTimers are the authors whose first PR was merged in the range
+	FirstTimers []*FirstContribution
+	// FirstTimersUnchecked are the authors whose earlier PRs could not
+	// be looked up
+	FirstTimersUnchecked []*User
+}
+
+// GetReleaseRange returns the tags of the named release (by tag or
+// name) and the release before it
+func (f *Fetcher) GetReleaseRange(ctx context.Context, owner, repo, name string) (string, string, error) {
+	var releases []*github.RepositoryRelease
+	err := doListOp(func(page int) (*github.Response, error) {
+		ghReleases, resp, err := f.Client.Repositories.ListReleases(ctx, owner, repo, &github.ListOptions{Page: page})
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range ghReleases {
+			if !r.GetDraft() {
+				releases = append(releases, r)
+			}
+		}
+		return resp, nil
+	})
+	if err != nil {
+		return "", "", err
+	}
+	// Releases are listed newest first
+	for n, r := range releases {
+		if r.GetTagName() != name && r.GetName() != name {
+			continue
+		}
+		if n+1 == len(releases) {
+			return "", "", fmt.Errorf("%s is the first release of %s/%s", name, owner, repo)
+		}
+		return releases[n+1].GetTagName(), r.GetTagName(), nil
+	}
+	return "", "", fmt.Errorf("no release %s in %s/%s", name, owner, repo)
+}
+
+// NewChangelog creates a changelog of the PRs merged between the tags
+// (or other commit-ish) from and to
+func (f *Fetcher) NewChangelog(ctx context.Context, baseURL, owner, repo, from, to string) (*Changelog, error) {
+	var cmp *github.CommitsComparison
+	_, err := doRetry(func() (*github.Response, error) {
+		var resp *github.Response
+		var err error
+		cmp, resp, err = f.Client.Repositories.CompareCommits(ctx, owner, repo, from, to)
+		return resp, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	cl := &Changelog{
+		BaseURL: baseURL,
+		Repo:    fmt.Sprintf("%s/%s", owner, repo),
+		From:    from,
+		To:      to,
+	}
+	if len(cmp.Commits) == 0 {
+		return cl, nil
+	}
+
+	since := cmp.GetBaseCommit().GetCommit().GetCommitter().GetDate()
+	until := cmp.Commits[len(cmp.Commits)-1].GetCommit().GetCommitter().GetDate()
+	shas := make(map[string]bool)
+	for _, c := range cmp.Commits {
+		shas[c.GetSHA()] = true
+	}
+	// The API only returns a limited number of commits. If some are
+	// missing fall back to the merge time.
+	complete := len(cmp.Commits) == cmp.GetTotalCommits()
+
+	users := make(Users)
+	var prs Items
+	if err := f.GetPRs(ctx, owner, repo, "all", &since, &prs, &users); err != nil {
+		return nil, err
+	}
+
+	var merged Items
+	for _, pr := range prs {
+		if !pr.Merged {
+			continue
+		}
+		if complete && !shas[pr.MergeCommitSHA] {
+			continue
+		}
+		if !complete && (!pr.MergedAt.After(since) || pr.MergedAt.After(until)) {
+			continue
+		}
+		merged = append(merged, pr)
+	}
+
+	groups := make(map[string]*ItemGroup)
+	authors := make(map[string]*User)
+	for _, pr := range merged {
+		name := changelogCategory(pr)
+		g, ok := groups[name]
+		if !ok {
+			g = &ItemGroup{Label: name}
+			groups[name] = g
+		}
+		g.Items = append(g.Items, pr)
+		if pr.CreatedBy != nil {
+			authors[pr.CreatedBy.ID] = pr.CreatedBy
+		}
+	}
+	for _, c := range changelogCategories {
+		if g, ok := groups[c.Name]; ok {
+			cl.Categories = append(cl.Categories, g)
+		}
+	}
+	if g, ok := groups["Other"]; ok {
+		cl.Categories = append(cl.Categories, g)
+	}
+	for _, g := range cl.Categories {
+		sort.Slice(g.Items, func(i, j int) bool { return g.Items[i].Number < g.Items[j].Number })
+	}
+
+	for _, u := range authors {
+		cl.Contributors = append(cl.Contributors, u)
+	}
+	sort.Slice(cl.Contributors, func(i, j int) bool { return cl.Contributors[i].ID < cl.Contributors[j].ID })
+
+	for _, u := range cl.Contributors {
+		first, err := f.isFirstMergedPR(ctx, cl.Repo, u.ID, since)
+		if err != nil {
+			warnf("Error checking earlier PRs of %s: %v\n", u.ID, err)
+			f.addIncomplete("@"+u.ID, fmt.Errorf("earlier PRs: %v", err))
+			cl.FirstTimersUnchecked = append(cl.FirstTimersUnchecked, u)
+			continue
+		}
+		if !first {
+			continue
+		}
+		// Credit the oldest PR of the range
+		var fc *FirstContribution
+		for _, pr := range merged {
+			if pr.CreatedBy != nil && pr.CreatedBy.ID == u.ID && (fc == nil || pr.MergedAt.Before(fc.Item.MergedAt)) {
+				fc = &FirstContribution{User: u, Item: pr}
+			}
+		}
+		cl.FirstTimers = append(cl.FirstTimers, fc)
+	}
+	return cl, nil
+}
+
+// isFirstMergedPR returns true if the user had no PR merged in the
+// repository before the given time
+func (f *Fetcher) isFirstMergedPR(ctx context.Context, repo, user string, before time.Time) (bool, error) {
+	q := fmt.Sprintf("repo:%s is:pr is:merged author:%s merged:<%s", repo, user, before.Format(time.RFC3339))
+	n, err := f.searchCount(ctx, q)
+	return n == 0, err
+}
+
+// Items returns all PRs of the changelog
+func (cl *Changelog) Items() Items {
+	var items Items
+	for _, g := range cl.Categories {
+		items = append(items, g.Items...)
+	}
+	return items
+}
+
+// Users returns the contributors of the changelog
+func (cl *Changelog) Users() Users {
+	users := make(Users)
+	for _, u := range cl.Contributors {
+		users[u.ID] = u
+	}
+	return users
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/github"
+)
+
+func TestChangelogCategory(t *testing.T) {
+	for _, tc := range []struct {
+		labels string
+		want   string
+	}{
+		{"kind/feature", "Features"},
+		{"Enhancement", "Features"},
+		{"kind/bug", "Fixes"},
+		{"area/docs", "Documentation"},
+		{"documentation,kind/bug", "Fixes"},
+		{"area/docker", "Other"},
+		{"prefix/fix-me", "Other"},
+		{"fix/network", "Other"},
+		{"", "Other"},
+	} {
+		i := &Item{}
+		if tc.labels != "" {
+			i.Labels = strings.Split(tc.labels, ",")
+		}
+		if got := changelogCategory(i); got != tc.want {
+			t.Errorf("changelogCategory(%s) = %s, want %s", tc.labels, got, tc.want)
+		}
+	}
+}
+
+func TestGetReleaseRange(t *testing.T) {
+	f := newFakeGitHub()
+	defer f.Close()
+	release := func(tag, name string, draft bool) *github.RepositoryRelease {
+		return &github.RepositoryRelease{TagName: github.String(tag), Name: github.String(name), Draft: github.Bool(draft)}
+	}
+	// Releases are listed newest first
+	f.AddList("/repos/o/r/releases", release("v0.3", "Third", false), release("v0.2.1", "", true),
+		release("v0.2", "Second", false), release("v0.1", "First", false))
+
+	fetcher := NewFetcher(f.Client(t), nil, 1)
+	for _, tc := range []struct {
+		name, from, to string
+	}{
+		{"v0.3", "v0.2", "v0.3"},
+		{"Second", "v0.1", "v0.2"},
+		{"v0.1", "", ""},
+		{"v0.2.1", "", ""},
+	} {
+		from, to, err := fetcher.GetReleaseRange(context.Background(), "o", "r", tc.name)
+		if tc.from == "" {
+			if err == nil {
+				t.Errorf("%s: got %s..%s, want an error", tc.name, from, to)
+			}
+			continue
+		}
+		if err != nil || from != tc.from || to != tc.to {
+			t.Errorf("%s: got %s..%s (%v), want %s..%s", tc.name, from, to, err, tc.from, tc.to)
+		}
+	}
+}
+
+// getChangelog creates the changelog of the fake repository between
+// two tags, with o/r#1 as the only commit
+func getChangelog(t *testing.T, f *fakeGitHub) (*Fetcher, *Changelog) {
+	f.AddObject("/repos/o/r/compare/v0.1...v0.2", &github.CommitsComparison{
+		BaseCommit: &github.RepositoryCommit{Commit: &github.Commit{
+			Committer: &github.CommitAuthor{Date: ghTime("2018-03-01T10:00:00Z")}}},
+		Commits: []github.RepositoryCommit{{SHA: github.String("abc"), Commit: &github.Commit{
+			Committer: &github.CommitAuthor{Date: ghTime("2018-03-05T10:00:00Z")}}}},
+		TotalCommits: github.Int(1),
+	})
+	fetcher := NewFetcher(f.Client(t), nil, 2)
+	cl, err := fetcher.NewChangelog(context.Background(), "https://github.com/", "o", "r", "v0.1", "v0.2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fetcher, cl
+}
+
+func TestNewChangelog(t *testing.T) {
+	defer fastRetries()()
+	f := newFakeRepo()
+	defer f.Close()
+	f.Fail("/repos/o/r/compare/v0.1...v0.2", http.StatusBadGateway, 1)
+	f.AddObject("/search/issues", &github.IssuesSearchResult{Total: github.Int(0)})
+
+	_, cl := getChangelog(t, f)
+	if len(cl.Categories) != 1 || cl.Categories[0].Label != "Features" || ids(cl.Categories[0].Items) != "o/r#1" {
+		t.Errorf("got categories %v, want o/r#1 as a feature", cl.Categories)
+	}
+	if len(cl.Contributors) != 1 || cl.Contributors[0].ID != "alice" {
+		t.Errorf("got contributors %v, want alice", cl.Contributors)
+	}
+	if len(cl.FirstTimers) != 1 || cl.FirstTimers[0].User.ID != "alice" || cl.FirstTimers[0].Item.ID != "o/r#1" {
+		t.Errorf("got first-timers %v, want alice with o/r#1", cl.FirstTimers)
+	}
+}
+
+func TestNewChangelogSearchFails(t *testing.T) {
+	defer fastRetries()()
+	f := newFakeRepo()
+	defer f.Close()
+	f.Fail("/search/issues", http.StatusInternalServerError, -1)
+
+	fetcher, cl := getChangelog(t, f)
+	if len(cl.FirstTimers) != 0 {
+		t.Errorf("got first-timers %v, want none", cl.FirstTimers)
+	}
+	if len(cl.FirstTimersUnchecked) != 1 || cl.FirstTimersUnchecked[0].ID != "alice" {
+		t.Errorf("got unchecked first-timers %v, want alice", cl.FirstTimersUnchecked)
+	}
+	incomplete := fetcher.Incomplete()
+	if len(incomplete) != 1 || incomplete[0].ID != "@alice" {
+		t.Errorf("got incomplete %v, want @alice", incomplete)
+	}
+	var buf bytes.Buffer
+	if err := cl.Markdown(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "Earlier PRs could not be checked for [@alice]") {
+		t.Errorf("unchecked first-timers are not named:\n%s", buf.String())
+	}
+}
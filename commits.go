@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/github"
+)
+
+// Commit is a commit on the default branch of a repository
+type Commit struct {
+	ID   string
+	Repo string
+	SHA  string
+	// Title is the first line of the commit message
+	Title string
+	URL   string
+	// Author is nil if the commit author is not a GitHub user.
+	// AuthorName is always set.
+	Author      *User
+	AuthorName  string
+	CommittedAt time.Time
+	Parents     []string
+}
+
+// NewCommit creates a Commit from a GH repository commit
+func NewCommit(c *github.RepositoryCommit, repo string, users *Users) *Commit {
+	commit := &Commit{
+		Repo:        repo,
+		SHA:         c.GetSHA(),
+		Title:       strings.SplitN(c.GetCommit().GetMessage(), "\n", 2)[0],
+		URL:         c.GetHTMLURL(),
+		AuthorName:  c.GetCommit().GetAuthor().GetName(),
+		CommittedAt: c.GetCommit().GetCommitter().GetDate(),
+	}
+	commit.ID = fmt.Sprintf("%s@%.7s", repo, commit.SHA)
+	if c.Author != nil && c.Author.Login != nil {
+		commit.Author = users.Add(c.Author)
+		commit.AuthorName = commit.Author.ID
+	}
+	for _, p := range c.Parents {
+		commit.Parents = append(commit.Parents, p.GetSHA())
+	}
+	return commit
+}
+
+func (c *Commit) String() string {
+	author := c.AuthorName
+	if c.Author != nil {
+		author = c.Author.String()
+	}
+	return fmt.Sprintf("%s ([%s] %s)", c.Title, c.ID, author)
+}
+
+// Link returns a markdown style link to the commit
+func (c *Commit) Link() string {
+	return fmt.Sprintf("[%s]: %s", c.ID, c.URL)
+}
+
+// Commits is a list of Commits
+type Commits []*Commit
+
+// String return a string of the list of Commits in markdown
+func (commits Commits) String() string {
+	var ret string
+	var repo string
+	for _, c := range commits {
+		if c.Repo != repo && repo != "" {
+			ret += "\n"
+		}
+		repo = c.Repo
+		ret += "\n- " + c.String()
+	}
+	return ret
+}
+
+// Links returns the markdown style links to the Commits
+func (commits Commits) Links() string {
+	var links []string
+	for _, c := range commits {
+		links = append(links, c.Link())
+	}
+	return strings.Join(links, "\n")
+}
+
+// GetCommits gets the commits on the default branch of a list of
+// owner/repo repositories in a period. The commits of a repository
+// are listed newest first, the repositories in the order of repos.
+func (f *Fetcher) GetCommits(ctx context.Context, repos []string, period *Period, users *Users) Commits {
+	commits := make([]Commits, len(repos))
+	f.forEach(len(repos), func(n int) {
+		t := strings.SplitN(repos[n], "/", 2)
+		if len(t) != 2 {
+			warnf("%s is malformed.\n", repos[n])
+			return
+		}
+		infof("Get commits for %s:\n", repos[n])
+		err := doListOp(func(page int) (*github.Response, error) {
+			opts := &github.CommitsListOptions{Since: period.Start, Until: period.End}
+			opts.ListOptions.Page = page
+			ghCommits, resp, err := f.Client.Repositories.ListCommits(ctx, t[0], t[1], opts)
+			if err != nil {
+				return nil, err
+			}
+			for _, c := range ghCommits {
+				commits[n] = append(commits[n], NewCommit(c, repos[n], users))
+			}
+			return resp, nil
+		})
+		if err != nil {
+			warnf("Error getting commits for %s: %v\n", repos[n], err)
+			f.addIncomplete(repos[n], fmt.Errorf("commits: %v", err))
+		}
+	})
+
+	var all Commits
+	for _, c := range commits {
+		all = append(all, c...)
+	}
+	return all
+}
+
+// CommitActivity describes the commits on the default branches in a period
+type CommitActivity struct {
+	Count int
+	// Authors is the number of distinct commit authors
+	Authors int
+	// Direct lists the commits which did not land through a merged PR
+	Direct Commits
+}
+
+// NewCommitActivity computes the commit activity from the commits of a
+// period and the PRs of the same repositories.
+//
+// Only the commits on the first parent chain of a branch are checked.
+// Others were brought in by a merge commit. A commit is associated with
+// a PR if it is the merge commit of the PR or, for rebased PRs, was
+// committed at the time the PR was merged.
+func NewCommitActivity(commits Commits, prs Items) *CommitActivity {
+	ca := &CommitActivity{Count: len(commits)}
+
+	mergeSHAs := make(map[string]bool)
+	mergeTimes := make(map[string]bool)
+	for _, pr := range prs {
+		if !pr.Merged {
+			continue
+		}
+		mergeSHAs[pr.MergeCommitSHA] = true
+		mergeTimes[fmt.Sprintf("%s %d", pr.Repo, pr.MergedAt.Unix())] = true
+	}
+
+	// Commits are listed newest first per repository, so the first
+	// commit of a repository is the head of its first parent chain
+	byID := make(map[string]*Commit)
+	for _, c := range commits {
+		byID[c.Repo+c.SHA] = c
+	}
+	mainline := make(map[*Commit]bool)
+	authors := make(map[string]bool)
+	var repo string
+	for _, c := range commits {
+		authors[c.AuthorName] = true
+		if c.Repo == repo {
+			continue
+		}
+		repo = c.Repo
+		for head := c; head != nil; {
+			mainline[head] = true
+			if len(head.Parents) == 0 {
+				break
+			}
+			head = byID[repo+head.Parents[0]]
+		}
+	}
+	ca.Authors = len(authors)
+
+	for _, c := range commits {
+		if !mainline[c] {
+			continue
+		}
+		if mergeSHAs[c.SHA] || mergeTimes[fmt.Sprintf("%s %d", c.Repo, c.CommittedAt.Unix())] {
+			continue
+		}
+		ca.Direct = append(ca.Direct, c)
+	}
+	return ca
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewCommitActivity(t *testing.T) {
+	at := func(s string) time.Time { return *ghTime("2018-03-" + s + ":00Z") }
+	commit := func(repo, sha, committed, author string, parents ...string) *Commit {
+		return &Commit{ID: repo + "@" + sha, Repo: repo, SHA: sha, AuthorName: author,
+			CommittedAt: at(committed), Parents: parents}
+	}
+	merged := func(repo string, number int, sha, mergedAt string) *Item {
+		return &Item{PR: true, Repo: repo, Number: number, Merged: true, MergeCommitSHA: sha, MergedAt: at(mergedAt)}
+	}
+
+	for _, tc := range []struct {
+		name    string
+		commits Commits
+		prs     Items
+		authors int
+		direct  string
+	}{
+		{"merge commit",
+			Commits{
+				commit("o/r", "m1", "05T12:00", "bob", "base", "f2"),
+				commit("o/r", "f2", "04T12:00", "alice", "f1"),
+				commit("o/r", "f1", "03T12:00", "alice", "base"),
+			},
+			Items{merged("o/r", 1, "m1", "05T12:00")}, 2, ""},
+		{"squash merge",
+			Commits{commit("o/r", "s1", "05T12:00", "alice", "base")},
+			Items{merged("o/r", 1, "s1", "05T12:00")}, 1, ""},
+		{"rebase merge",
+			Commits{
+				commit("o/r", "r2", "05T12:00", "alice", "r1"),
+				commit("o/r", "r1", "05T12:00", "alice", "base"),
+			},
+			Items{merged("o/r", 1, "r2", "05T12:00")}, 1, ""},
+		{"direct push",
+			Commits{commit("o/r", "d1", "05T12:00", "carol", "base")},
+			nil, 1, "o/r@d1"},
+		{"direct push on top of a merge",
+			Commits{
+				commit("o/r", "d1", "06T12:00", "carol", "m1"),
+				commit("o/r", "m1", "05T12:00", "bob", "base", "f1"),
+				commit("o/r", "f1", "04T12:00", "alice", "base"),
+			},
+			Items{merged("o/r", 1, "m1", "05T12:00")}, 3, "o/r@d1"},
+		{"merge time of another repository",
+			Commits{
+				commit("o/r", "s1", "05T12:00", "alice", "base"),
+				commit("o/s", "d1", "05T12:00", "carol", "base"),
+			},
+			Items{merged("o/r", 1, "s1", "05T12:00")}, 2, "o/s@d1"},
+		{"unmerged PR",
+			Commits{commit("o/r", "d1", "05T12:00", "carol", "base")},
+			Items{{PR: true, Repo: "o/r", Number: 1, MergeCommitSHA: "d1"}}, 1, "o/r@d1"},
+	} {
+		ca := NewCommitActivity(tc.commits, tc.prs)
+		if ca.Count != len(tc.commits) {
+			t.Errorf("%s: got %d commits, want %d", tc.name, ca.Count, len(tc.commits))
+		}
+		if ca.Authors != tc.authors {
+			t.Errorf("%s: got %d authors, want %d", tc.name, ca.Authors, tc.authors)
+		}
+		var direct []string
+		for _, c := range ca.Direct {
+			direct = append(direct, c.ID)
+		}
+		if got := strings.Join(direct, " "); got != tc.direct {
+			t.Errorf("%s: got direct commits %q, want %q", tc.name, got, tc.direct)
+		}
+	}
+}
+
+func TestGetCommitsFails(t *testing.T) {
+	defer fastRetries()()
+	f := newFakeRepo()
+	defer f.Close()
+	f.Fail("/repos/o/r/commits", http.StatusInternalServerError, -1)
+
+	period, _ := NewPeriodFromMonth("2018-03")
+	fetcher := NewFetcher(f.Client(t), nil, 1)
+	users := make(Users)
+	if commits := fetcher.GetCommits(context.Background(), []string{"o/r"}, period, &users); len(commits) != 0 {
+		t.Errorf("got %d commits", len(commits))
+	}
+	incomplete := fetcher.Incomplete()
+	if len(incomplete) != 1 || incomplete[0].ID != "o/r" {
+		t.Errorf("got incomplete %v, want o/r", incomplete)
+	}
+}
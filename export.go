@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+)
+
+// sqlSchema is the schema of the SQLite export. Times are stored as
+// RFC 3339 text in UTC and are NULL if unset, booleans as 0 or 1.
+// Comments and reviews have no ID on their own and are numbered per
+// Item in the order they were made.
+const sqlSchema = `CREATE TABLE IF NOT EXISTS repos (
+	name TEXT PRIMARY KEY,        -- owner/repo
+	exported_at TEXT NOT NULL     -- last export of the repository
+);
+CREATE TABLE IF NOT EXISTS users (
+	id TEXT PRIMARY KEY,          -- GitHub login
+	url TEXT,
+	avatar_url TEXT,
+	bot INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS items (
+	id TEXT PRIMARY KEY,          -- owner/repo#number
+	repo TEXT NOT NULL REFERENCES repos(name),
+	number INTEGER NOT NULL,
+	pr INTEGER NOT NULL,          -- 1 for PRs, 0 for Issues
+	state TEXT NOT NULL,          -- open or closed
+	title TEXT NOT NULL,
+	url TEXT NOT NULL,
+	created_by TEXT REFERENCES users(id),
+	author_association TEXT,      -- e.g. MEMBER or CONTRIBUTOR
+	created_at TEXT NOT NULL,
+	updated_at TEXT NOT NULL,
+	closed_at TEXT,
+	merged INTEGER NOT NULL,
+	merged_at TEXT,
+	merged_by TEXT REFERENCES users(id),
+	merge_commit_sha TEXT,
+	additions INTEGER NOT NULL,
+	deletions INTEGER NOT NULL,
+	changed_files INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS labels (
+	item_id TEXT NOT NULL REFERENCES items(id),
+	name TEXT NOT NULL,
+	PRIMARY KEY (item_id, name)
+);
+CREATE TABLE IF NOT EXISTS comments (
+	item_id TEXT NOT NULL REFERENCES items(id),
+	seq INTEGER NOT NULL,
+	kind TEXT NOT NULL,           -- comment or review_comment
+	user TEXT REFERENCES users(id),
+	created_at TEXT NOT NULL,
+	PRIMARY KEY (item_id, seq)
+);
+CREATE TABLE IF NOT EXISTS reviews (
+	item_id TEXT NOT NULL REFERENCES items(id),
+	seq INTEGER NOT NULL,
+	state TEXT NOT NULL,          -- APPROVED, CHANGES_REQUESTED, COMMENTED or DISMISSED
+	user TEXT REFERENCES users(id),
+	submitted_at TEXT NOT NULL,
+	PRIMARY KEY (item_id, seq)
+);
+`
+
+// sqlString returns s as a quoted SQL string literal
+func sqlString(s string) string {
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
+
+// sqlNullString returns s as a SQL string literal or NULL if s is empty
+func sqlNullString(s string) string {
+	if s == "" {
+		return "NULL"
+	}
+	return sqlString(s)
+}
+
+// sqlTime returns t as a SQL string literal or NULL for the zero time
+func sqlTime(t time.Time) string {
+	if t.IsZero() {
+		return "NULL"
+	}
+	return sqlString(t.UTC().Format(time.RFC3339))
+}
+
+func sqlBool(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+func sqlUser(u *User) string {
+	if u == nil {
+		return "NULL"
+	}
+	return sqlString(u.ID)
+}
+
+// ExportSQL writes SQL statements to w which create the export schema
+// if needed and insert or update the Items and users. Labels, comments
+// and reviews of an exported Item replace the stored ones. The
+// statements run in a single transaction.
+func ExportSQL(w io.Writer, items Items, users Users, exportedAt time.Time) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "BEGIN TRANSACTION;")
+	fmt.Fprint(bw, sqlSchema)
+
+	var ids []string
+	for id := range users {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		u := users[id]
+		fmt.Fprintf(bw, "INSERT OR REPLACE INTO users VALUES (%s, %s, %s, %s);\n",
+			sqlString(u.ID), sqlNullString(u.URL), sqlNullString(u.AvatarURL), sqlBool(u.Bot))
+	}
+
+	repos := make(map[string]bool)
+	for _, i := range items {
+		if !repos[i.Repo] {
+			repos[i.Repo] = true
+			fmt.Fprintf(bw, "INSERT OR REPLACE INTO repos VALUES (%s, %s);\n", sqlString(i.Repo), sqlTime(exportedAt))
+		}
+
+		fmt.Fprintf(bw, "INSERT OR REPLACE INTO items VALUES (%s, %s, %d, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %d, %d, %d);\n",
+			sqlString(i.ID), sqlString(i.Repo), i.Number, sqlBool(i.PR), sqlString(i.State),
+			sqlString(i.Title), sqlString(i.URL), sqlUser(i.CreatedBy), sqlNullString(i.AuthorAssociation),
+			sqlTime(i.CreatedAt), sqlTime(i.UpdatedAt), sqlTime(i.ClosedAt),
+			sqlBool(i.Merged), sqlTime(i.MergedAt), sqlUser(i.MergedBy), sqlNullString(i.MergeCommitSHA),
+			i.Additions, i.Deletions, i.ChangedFiles)
+
+		id := sqlString(i.ID)
+		for _, table := range []string{"labels", "comments", "reviews"} {
+			fmt.Fprintf(bw, "DELETE FROM %s WHERE item_id = %s;\n", table, id)
+		}
+		for _, l := range i.Labels {
+			fmt.Fprintf(bw, "INSERT OR REPLACE INTO labels VALUES (%s, %s);\n", id, sqlString(l))
+		}
+		var comments, reviews int
+		for _, c := range i.Comments {
+			if c.Kind == CommentReview {
+				reviews++
+				fmt.Fprintf(bw, "INSERT INTO reviews VALUES (%s, %d, %s, %s, %s);\n",
+					id, reviews, sqlString(c.State), sqlUser(c.User), sqlTime(c.CreatedAt))
+				continue
+			}
+			comments++
+			fmt.Fprintf(bw, "INSERT INTO comments VALUES (%s, %d, %s, %s, %s);\n",
+				id, comments, sqlString(c.Kind), sqlUser(c.User), sqlTime(c.CreatedAt))
+		}
+	}
+
+	fmt.Fprintln(bw, "COMMIT;")
+	return bw.Flush()
+}
+
+// completeItems returns the Items which were fetched completely.
+// Exporting the others would replace the stored comments and reviews
+// with partial ones.
+func completeItems(items Items, incomplete []*FetchError) Items {
+	skip := make(map[string]bool)
+	for _, fe := range incomplete {
+		skip[fe.ID] = true
+	}
+	var ret Items
+	for _, i := range items {
+		if skip[i.ID] {
+			warnf("Not exporting incompletely fetched %s\n", i.ID)
+			continue
+		}
+		ret = append(ret, i)
+	}
+	return ret
+}
+
+// ExportSQLite exports the Items and users into a SQLite database,
+// creating it if it does not exist. Items listed in incomplete are
+// skipped, keeping what was exported of them before. The statements
+// are run by the sqlite3 command line tool which must be installed. A
+// database of "-" writes the statements to stdout instead.
+func ExportSQLite(db string, items Items, users Users, incomplete []*FetchError) error {
+	items = completeItems(items, incomplete)
+	if db == "-" {
+		return ExportSQL(os.Stdout, items, users, time.Now())
+	}
+
+	cmd := exec.Command("sqlite3", "-bail", db)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("running sqlite3: %v", err)
+	}
+	err = ExportSQL(stdin, items, users, time.Now())
+	stdin.Close()
+	if werr := cmd.Wait(); werr != nil {
+		return fmt.Errorf("sqlite3: %v", werr)
+	}
+	return err
+}
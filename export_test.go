@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// exportItems fetches the Items of the fake repository for March 2018
+func exportItems(t *testing.T) (Items, Users) {
+	f := newFakeRepo()
+	defer f.Close()
+
+	period, _ := NewPeriodFromMonth("2018-03")
+	fetcher := NewFetcher(f.Client(t), nil, 2)
+	users := make(Users)
+	prs, issues := fetcher.GetItems(context.Background(), []string{"o/r"}, "all", &period.Start, &users)
+	return append(prs, issues...), users
+}
+
+var exportedAt = time.Date(2018, 4, 1, 12, 0, 0, 0, time.UTC)
+
+func TestExportSQLGolden(t *testing.T) {
+	items, users := exportItems(t)
+	items[1].Title = "Don't break quoting"
+
+	var buf bytes.Buffer
+	if err := ExportSQL(&buf, items, users, exportedAt); err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "export.sql", buf.Bytes())
+}
+
+func TestExportSQLite(t *testing.T) {
+	if _, err := exec.LookPath("sqlite3"); err != nil {
+		t.Skip("sqlite3 is not installed")
+	}
+	dir, err := ioutil.TempDir("", "gh-report-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db := filepath.Join(dir, "gh-report.db")
+
+	query := func(q string) string {
+		out, err := exec.Command("sqlite3", db, q).CombinedOutput()
+		if err != nil {
+			t.Fatalf("%s: %v: %s", q, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	export := func(items Items, users Users) {
+		var buf bytes.Buffer
+		if err := ExportSQL(&buf, items, users, exportedAt); err != nil {
+			t.Fatal(err)
+		}
+		cmd := exec.Command("sqlite3", "-bail", db)
+		cmd.Stdin = &buf
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("sqlite3: %v: %s", err, out)
+		}
+	}
+
+	items, users := exportItems(t)
+	export(items, users)
+	if got := query("SELECT count(*) FROM items"); got != "5" {
+		t.Errorf("got %s items, want 5", got)
+	}
+	if got := query("SELECT state FROM reviews WHERE item_id = 'o/r#1' ORDER BY seq"); got != "CHANGES_REQUESTED\nCOMMENTED\nAPPROVED" {
+		t.Errorf("got reviews %q", got)
+	}
+
+	// Exporting again updates the rows instead of adding new ones
+	pr1 := items[2]
+	pr1.Labels = nil
+	pr1.Comments = pr1.Comments[:1]
+	export(items[2:3], users)
+	if got := query("SELECT count(*) FROM items"); got != "5" {
+		t.Errorf("got %s items after update, want 5", got)
+	}
+	if got := query("SELECT count(*) FROM labels WHERE item_id = 'o/r#1'"); got != "0" {
+		t.Errorf("got %s labels of o/r#1, want 0", got)
+	}
+	if got := query("SELECT (SELECT count(*) FROM comments WHERE item_id = 'o/r#1') + (SELECT count(*) FROM reviews WHERE item_id = 'o/r#1')"); got != "1" {
+		t.Errorf("got %s comments and reviews of o/r#1, want 1", got)
+	}
+	if got := query("SELECT count(*) FROM labels"); got != "2" {
+		t.Errorf("got %s labels, want the 2 of the other Items", got)
+	}
+
+	// Incompletely fetched Items keep what was exported before
+	issue2 := items[4]
+	issue2.Title = "Updated"
+	issue2.Comments = nil
+	incomplete := []*FetchError{{ID: issue2.ID}}
+	if err := ExportSQLite(db, items[4:], users, incomplete); err != nil {
+		t.Fatal(err)
+	}
+	if got := query("SELECT title || ' ' || (SELECT count(*) FROM comments WHERE item_id = 'o/r#2') FROM items WHERE id = 'o/r#2'"); got != "Crash on start 1" {
+		t.Errorf("incomplete o/r#2 was exported: %s", got)
+	}
+}
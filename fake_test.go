@@ -0,0 +1,257 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/github"
+)
+
+// fakeGitHub is an in-process fake of the parts of the GitHub API used
+// to fetch PRs and Issues. It serves the API under /api/v3/ like GitHub
+// Enterprise does.
+type fakeGitHub struct {
+	*httptest.Server
+	// PerPage is the number of entries per page of a list
+	PerPage int
+
+	mu sync.Mutex
+	// lists are the entries returned by list endpoints keyed by path,
+	// e.g. /repos/o/r/pulls
+	lists map[string][]interface{}
+	// objects are returned by all other endpoints keyed by path
+	objects map[string]interface{}
+	// remaining is the number of requests left in the rate limit
+	// until reset. Once the reset time passed it starts over.
+	remaining int
+	reset     time.Time
+	// failures are served instead of the normal response keyed by path
+	failures map[string]*fakeFailure
+	requests []string
+}
+
+// fakeFailure is an error response served a number of times
+type fakeFailure struct {
+	status int
+	header http.Header
+	body   string
+	// n is the number of times left, negative for always
+	n int
+}
+
+// newFakeGitHub starts a fake GitHub server. It must be stopped with Close.
+func newFakeGitHub() *fakeGitHub {
+	f := &fakeGitHub{
+		PerPage:   30,
+		lists:     make(map[string][]interface{}),
+		objects:   make(map[string]interface{}),
+		failures:  make(map[string]*fakeFailure),
+		remaining: 5000,
+		reset:     time.Now().Add(time.Hour),
+	}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serve))
+	return f
+}
+
+// Client returns a GitHub client talking to the fake
+func (f *fakeGitHub) Client(t *testing.T) *github.Client {
+	client, err := newClient(f.Server.Client(), f.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+// SetRateLimit sets the number of requests left until the reset time
+func (f *fakeGitHub) SetRateLimit(remaining int, reset time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.remaining = remaining
+	f.reset = reset
+}
+
+// Fail makes the next n requests to path fail with a server error.
+// If n is negative all requests fail.
+func (f *fakeGitHub) Fail(path string, status, n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures[path] = &fakeFailure{
+		status: status,
+		header: make(http.Header),
+		body:   `{"message": "Server Error"}`,
+		n:      n,
+	}
+}
+
+// FailAbuse makes the next n requests to path hit the secondary (abuse)
+// rate limit, asking to retry after the given number of seconds
+func (f *fakeGitHub) FailAbuse(path string, retryAfter, n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	header := make(http.Header)
+	header.Set("Retry-After", strconv.Itoa(retryAfter))
+	f.failures[path] = &fakeFailure{
+		status: http.StatusForbidden,
+		header: header,
+		body:   `{"message": "You have triggered an abuse detection mechanism.", "documentation_url": "https://developer.github.com/v3/#abuse-rate-limits"}`,
+		n:      n,
+	}
+}
+
+// FailSecondary makes the next n requests to path hit the secondary rate
+// limit as GitHub reports it today, asking to retry after the given
+// number of seconds
+func (f *fakeGitHub) FailSecondary(path string, retryAfter, n int) {
+	header := make(http.Header)
+	header.Set("Retry-After", strconv.Itoa(retryAfter))
+	f.FailSecondaryWith(path, http.StatusForbidden, header, n)
+}
+
+// FailSecondaryWith makes the next n requests to path hit the secondary
+// rate limit with the given status and headers
+func (f *fakeGitHub) FailSecondaryWith(path string, status int, header http.Header, n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures[path] = &fakeFailure{
+		status: status,
+		header: header,
+		body:   `{"message": "You have exceeded a secondary rate limit.", "documentation_url": "https://docs.github.com/rest/overview/rate-limits-for-the-rest-api#about-secondary-rate-limits"}`,
+		n:      n,
+	}
+}
+
+// AddList appends entries to the list served at path
+func (f *fakeGitHub) AddList(path string, entries ...interface{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.lists[path] = append(f.lists[path], entries...)
+}
+
+// AddObject sets the object served at path
+func (f *fakeGitHub) AddObject(path string, obj interface{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.objects[path] = obj
+}
+
+// Requests returns the method and URL of all requests made so far
+func (f *fakeGitHub) Requests() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.requests...)
+}
+
+func (f *fakeGitHub) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, r.Method+" "+r.URL.String())
+
+	if f.remaining == 0 && time.Now().After(f.reset) {
+		f.remaining = 5000
+		f.reset = time.Now().Add(time.Hour)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-RateLimit-Limit", "5000")
+	w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(f.reset.Unix(), 10))
+	if f.remaining == 0 {
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"message": "API rate limit exceeded for 127.0.0.1."}`)
+		return
+	}
+	f.remaining--
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(f.remaining))
+
+	path := strings.TrimPrefix(r.URL.Path, "/api/v3")
+	if fl, ok := f.failures[path]; ok && fl.n != 0 {
+		fl.n--
+		for k, v := range fl.header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(fl.status)
+		fmt.Fprint(w, fl.body)
+		return
+	}
+	if entries, ok := f.lists[path]; ok {
+		if since := r.URL.Query().Get("since"); since != "" {
+			entries = updatedSince(entries, since)
+		}
+		f.serveList(w, r, entries)
+		return
+	}
+	if obj, ok := f.objects[path]; ok {
+		json.NewEncoder(w).Encode(obj)
+		return
+	}
+	w.WriteHeader(http.StatusNotFound)
+	fmt.Fprint(w, `{"message": "Not Found"}`)
+}
+
+// serveList writes a page of entries with the Link header GitHub uses
+// for pagination
+func (f *fakeGitHub) serveList(w http.ResponseWriter, r *http.Request, entries []interface{}) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	last := (len(entries) + f.PerPage - 1) / f.PerPage
+	if last < 1 {
+		last = 1
+	}
+
+	link := func(page int, rel string) string {
+		u := *r.URL
+		q := u.Query()
+		q.Set("page", strconv.Itoa(page))
+		u.RawQuery = q.Encode()
+		return fmt.Sprintf(`<%s%s>; rel="%s"`, f.URL, u.String(), rel)
+	}
+	var links []string
+	if page < last {
+		links = append(links, link(page+1, "next"), link(last, "last"))
+	}
+	if page > 1 {
+		links = append(links, link(1, "first"), link(page-1, "prev"))
+	}
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+
+	start := (page - 1) * f.PerPage
+	end := start + f.PerPage
+	if start > len(entries) {
+		start = len(entries)
+	}
+	if end > len(entries) {
+		end = len(entries)
+	}
+	json.NewEncoder(w).Encode(entries[start:end])
+}
+
+// updatedSince returns the entries updated at or after since like the
+// since parameter of the Issues list does
+func updatedSince(entries []interface{}, since string) []interface{} {
+	t, err := time.Parse(time.RFC3339, since)
+	if err != nil {
+		return entries
+	}
+	var ret []interface{}
+	for _, e := range entries {
+		var updated struct {
+			UpdatedAt *time.Time `json:"updated_at"`
+		}
+		data, _ := json.Marshal(e)
+		json.Unmarshal(data, &updated)
+		if updated.UpdatedAt == nil || !updated.UpdatedAt.Before(t) {
+			ret = append(ret, e)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/github"
+)
+
+// Fetcher fetches PRs and Issues from GitHub. Up to Concurrency
+// repositories and Items are fetched in parallel.
+type Fetcher struct {
+	Client *github.Client
+	Cache  *Cache
+
+	concurrency int
+	sem         chan struct{}
+
+	mu         sync.Mutex
+	incomplete []*FetchError
+}
+
+// FetchError records an Item or repository which could not be fully fetched
+type FetchError struct {
+	ID  string
+	Err error
+}
+
+// fetchErrors collects the errors of fetching the parts of an Item
+type fetchErrors []error
+
+// add adds err, if not nil, for the part of an Item named what
+func (errs *fetchErrors) add(what string, err error) {
+	if err != nil {
+		*errs = append(*errs, fmt.Errorf("%s: %v", what, err))
+	}
+}
+
+func (errs fetchErrors) Error() string {
+	var s []string
+	for _, err := range errs {
+		s = append(s, err.Error())
+	}
+	return strings.Join(s, "; ")
+}
+
+// NewFetcher creates a new Fetcher with the given level of concurrency
+func NewFetcher(client *github.Client, cache *Cache, concurrency int) *Fetcher {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &Fetcher{
+		Client:      client,
+		Cache:       cache,
+		concurrency: concurrency,
+		sem:         make(chan struct{}, concurrency),
+	}
+}
+
+// addIncomplete records that an Item or repository could not be fully fetched
+func (f *Fetcher) addIncomplete(id string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.incomplete = append(f.incomplete, &FetchError{ID: id, Err: err})
+}
+
+// Incomplete returns the Items and repositories which could not be
+// fully fetched, sorted by ID
+func (f *Fetcher) Incomplete() []*FetchError {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ret := append([]*FetchError(nil), f.incomplete...)
+	sort.Slice(ret, func(i, j int) bool { return ret[i].ID < ret[j].ID })
+	return ret
+}
+
+// forEach calls fn for 0..n-1 on a pool of workers shared by all
+// users of the Fetcher and waits for all calls to complete.
+func (f *Fetcher) forEach(n int, fn func(n int)) {
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		f.sem <- struct{}{}
+		go func(i int) {
+			defer func() { <-f.sem }()
+			defer wg.Done()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// GetItems gets the PRs and Issues in a state (open, closed or all) of
+// a list of owner/repo repositories since a given time. Repositories are fetched in parallel, but the
+// returned Items are in the order of repos.
+func (f *Fetcher) GetItems(ctx context.Context, repos []string, state string, since *time.Time, users *Users) (Items, Items) {
+	prs := make([]Items, len(repos))
+	issues := make([]Items, len(repos))
+
+	// Repositories use their own pool as the Item workers
+	// are shared between them.
+	sem := make(chan struct{}, f.concurrency)
+	var wg sync.WaitGroup
+	for n, ownerAndRepo := range repos {
+		t := strings.SplitN(ownerAndRepo, "/", 2)
+		if len(t) != 2 {
+			warnf("%s is malformed.\n", ownerAndRepo)
+			continue
+		}
+		owner := t[0]
+		repo := t[1]
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(n int, ownerAndRepo, owner, repo string) {
+			defer func() { <-sem }()
+			defer wg.Done()
+
+			// Handle PRs
+			infof("Get PRs for %s:\n", ownerAndRepo)
+			if err := f.GetPRs(ctx, owner, repo, state, since, &prs[n], users); err != nil {
+				warnf("Error getting PRs for %s: %v\n", ownerAndRepo, err)
+				f.addIncomplete(ownerAndRepo, fmt.Errorf("PRs: %v", err))
+			}
+
+			// Handle issues
+			infof("Get Issues for %s:\n", ownerAndRepo)
+			if err := f.GetIssues(ctx, owner, repo, state, since, &issues[n], users); err != nil {
+				warnf("Error getting Issues for %s: %v\n", ownerAndRepo, err)
+				f.addIncomplete(ownerAndRepo, fmt.Errorf("Issues: %v", err))
+			}
+		}(n, ownerAndRepo, owner, repo)
+	}
+	wg.Wait()
+
+	var allPRs Items
+	var allIssues Items
+	for n := range repos {
+		allPRs = append(allPRs, prs[n]...)
+		allIssues = append(allIssues, issues[n]...)
+	}
+	return allPRs, allIssues
+}
+
+// searchCount returns the number of Issues and PRs matching a search query
+func (f *Fetcher) searchCount(ctx context.Context, q string) (int, error) {
+	debugf("  Search: %s\n", q)
+	var total int
+	// The search API has a much lower rate limit than the others
+	_, err := doRetry(func() (*github.Response, error) {
+		res, resp, err := f.Client.Search.Issues(ctx, q, &github.SearchOptions{ListOptions: github.ListOptions{PerPage: 1}})
+		if err == nil {
+			total = res.GetTotal()
+		}
+		return resp, err
+	})
+	return total, err
+}
+
+// allBefore returns true if all times are before t. nil times are
+// treated as the zero time.
+func allBefore(t time.Time, times ...*time.Time) bool {
+	for _, tt := range times {
+		if tt != nil && !tt.Before(t) {
+			return false
+		}
+	}
+	return true
+}
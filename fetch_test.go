@@ -0,0 +1,441 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/github"
+)
+
+var update = flag.Bool("update", false, "Update the golden files in testdata/")
+
+// checkGolden compares got with the golden file testdata/name
+func checkGolden(t *testing.T, name string, got []byte) {
+	golden := filepath.Join("testdata", name)
+	if *update {
+		if err := ioutil.WriteFile(golden, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s differs from the golden file, run go test -update to update it. Got:\n%s", name, got)
+	}
+}
+
+func ghTime(s string) *time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		panic(err)
+	}
+	return &t
+}
+
+func ghUser(login string) *github.User {
+	return &github.User{
+		Login:   github.String(login),
+		HTMLURL: github.String("https://github.com/" + login),
+		Type:    github.String("User"),
+	}
+}
+
+func ghPR(number int, title, author, created, updated string) *github.PullRequest {
+	return &github.PullRequest{
+		Number:    github.Int(number),
+		State:     github.String("open"),
+		Title:     github.String(title),
+		HTMLURL:   github.String(fmt.Sprintf("https://github.com/o/r/pull/%d", number)),
+		User:      ghUser(author),
+		CreatedAt: ghTime(created),
+		UpdatedAt: ghTime(updated),
+	}
+}
+
+func ghIssue(number int, title, author, created, updated string) *github.Issue {
+	return &github.Issue{
+		Number:    github.Int(number),
+		State:     github.String("open"),
+		Title:     github.String(title),
+		HTMLURL:   github.String(fmt.Sprintf("https://github.com/o/r/issues/%d", number)),
+		User:      ghUser(author),
+		CreatedAt: ghTime(created),
+		UpdatedAt: ghTime(updated),
+	}
+}
+
+func ghReview(user, state, submitted string) *github.PullRequestReview {
+	return &github.PullRequestReview{
+		User:        ghUser(user),
+		State:       github.String(state),
+		SubmittedAt: ghTime(submitted),
+	}
+}
+
+// newFakeRepo returns a fake GitHub serving the PRs and Issues of the
+// repository o/r. Lists are served in pages of two entries.
+func newFakeRepo() *fakeGitHub {
+	f := newFakeGitHub()
+	f.PerPage = 2
+
+	// PRs are listed most recently updated first
+	pr1 := ghPR(1, "Add feature", "alice", "2018-03-02T10:00:00Z", "2018-03-05T10:00:00Z")
+	pr1.State = github.String("closed")
+	pr1.ClosedAt = ghTime("2018-03-05T10:00:00Z")
+	pr1.MergedAt = ghTime("2018-03-05T10:00:00Z")
+	pr1.MergedBy = ghUser("bob")
+	pr1.MergeCommitSHA = github.String("abc")
+	pr3 := ghPR(3, "Fix typo", "carol", "2018-03-10T10:00:00Z", "2018-03-10T10:00:00Z")
+	pr5 := ghPR(5, "Rework everything", "alice", "2018-01-10T10:00:00Z", "2018-03-20T10:00:00Z")
+	pr5.State = github.String("closed")
+	pr5.ClosedAt = ghTime("2018-03-20T10:00:00Z")
+	pr6 := ghPR(6, "Ancient", "bob", "2017-11-01T10:00:00Z", "2018-02-01T10:00:00Z")
+	f.AddList("/repos/o/r/pulls", pr5, pr3, pr1, pr6)
+
+	// The list does not contain the size of PRs
+	for _, pr := range []*github.PullRequest{pr1, pr3, pr5, pr6} {
+		details := *pr
+		details.Additions = github.Int(10 * *pr.Number)
+		details.Deletions = github.Int(*pr.Number)
+		details.ChangedFiles = github.Int(1)
+		f.AddObject(fmt.Sprintf("/repos/o/r/pulls/%d", *pr.Number), &details)
+	}
+
+	f.AddList("/repos/o/r/pulls/1/comments", &listedPRComment{
+		PullRequestComment: github.PullRequestComment{User: ghUser("bob"), CreatedAt: ghTime("2018-03-03T10:00:00Z")},
+		AuthorAssociation:  "MEMBER",
+	})
+	f.AddList("/repos/o/r/pulls/1/reviews",
+		ghReview("bob", "CHANGES_REQUESTED", "2018-03-03T10:00:00Z"),
+		ghReview("alice", "COMMENTED", "2018-03-03T12:00:00Z"),
+		ghReview("bob", "APPROVED", "2018-03-04T10:00:00Z"),
+		&github.PullRequestReview{User: ghUser("dave"), State: github.String("PENDING")})
+	// Only PR #3 has a conversation and it is the first response to it
+	f.AddList("/repos/o/r/issues/1/comments")
+	f.AddList("/repos/o/r/issues/3/comments", &github.IssueComment{User: ghUser("erin"), CreatedAt: ghTime("2018-03-10T12:00:00Z")})
+	f.AddList("/repos/o/r/issues/5/comments")
+	f.AddList("/repos/o/r/issues/6/comments")
+	f.AddList("/repos/o/r/pulls/3/comments")
+	f.AddList("/repos/o/r/pulls/3/reviews")
+	f.AddList("/repos/o/r/pulls/5/comments")
+	f.AddList("/repos/o/r/pulls/5/reviews", ghReview("dave", "COMMENTED", "2018-03-15T10:00:00Z"))
+	f.AddList("/repos/o/r/issues/1/labels", &github.Label{Name: github.String("kind/feature")})
+	f.AddList("/repos/o/r/issues/3/labels", &github.Label{Name: github.String("kind/bug")})
+	f.AddList("/repos/o/r/issues/5/labels")
+	f.AddList("/repos/o/r/pulls/6/comments")
+	f.AddList("/repos/o/r/pulls/6/reviews")
+	f.AddList("/repos/o/r/issues/6/labels")
+
+	// Issues are listed most recently updated first and include PRs
+	issue2 := ghIssue(2, "Crash on start", "dave", "2018-03-04T10:00:00Z", "2018-03-08T10:00:00Z")
+	issue2.State = github.String("closed")
+	issue2.ClosedAt = ghTime("2018-03-08T10:00:00Z")
+	issue2.Labels = []github.Label{{Name: github.String("kind/bug")}}
+	issue4 := ghIssue(4, "Question", "erin", "2018-03-12T10:00:00Z", "2018-03-12T10:00:00Z")
+	prIssue := ghIssue(3, "Fix typo", "carol", "2018-03-10T10:00:00Z", "2018-03-10T10:00:00Z")
+	prIssue.PullRequestLinks = &github.PullRequestLinks{URL: github.String("https://api.github.com/repos/o/r/pulls/3")}
+	f.AddList("/repos/o/r/issues", issue4, prIssue, issue2)
+	f.AddList("/repos/o/r/issues/2/comments", &github.IssueComment{User: ghUser("alice"), CreatedAt: ghTime("2018-03-05T10:00:00Z")})
+	f.AddList("/repos/o/r/issues/4/comments")
+	return f
+}
+
+func TestGetItems(t *testing.T) {
+	f := newFakeRepo()
+	defer f.Close()
+
+	period, _ := NewPeriodFromMonth("2018-03")
+	fetcher := NewFetcher(f.Client(t), nil, 2)
+	users := make(Users)
+	prs, issues := fetcher.GetItems(context.Background(), []string{"o/r"}, "all", &period.Start, &users)
+
+	var got []string
+	for _, i := range append(prs, issues...) {
+		got = append(got, i.ID)
+	}
+	// PRs stop at the first one not updated since the start and
+	// the Issues do not contain PRs
+	if want := "o/r#5 o/r#3 o/r#1 o/r#4 o/r#2"; strings.Join(got, " ") != want {
+		t.Errorf("got %s, want %s", strings.Join(got, " "), want)
+	}
+
+	pr1 := prs[2]
+	if !pr1.Merged || pr1.MergedBy.ID != "bob" || pr1.Additions != 10 || pr1.Deletions != 1 {
+		t.Errorf("o/r#1 not filled in: %s", pr1.Dump())
+	}
+	if len(pr1.Comments) != 4 {
+		t.Errorf("o/r#1 has %d comments, want 4 without the pending review", len(pr1.Comments))
+	}
+	if pr3 := prs[1]; len(pr3.Comments) != 1 || pr3.Comments[0].Kind != CommentIssue {
+		t.Errorf("o/r#3 conversation not fetched: %s", pr3.Dump())
+	}
+	if strings.Join(pr1.Labels, ",") != "kind/feature" {
+		t.Errorf("o/r#1 has labels %v", pr1.Labels)
+	}
+	if users["bob"] != pr1.MergedBy || users["bob"] != pr1.Comments[0].User {
+		t.Errorf("users are not shared between items")
+	}
+	for _, r := range f.Requests() {
+		if strings.Contains(r, "/pulls/6") || strings.Contains(r, "/issues/6/") {
+			t.Errorf("unexpected request: %s", r)
+		}
+	}
+}
+
+func TestRateLimitReset(t *testing.T) {
+	f := newFakeRepo()
+	defer f.Close()
+	// The rate limit runs out while paging, but is already reset by
+	// the time the next page is requested
+	f.SetRateLimit(1, time.Now().Add(-time.Minute))
+
+	fetcher := NewFetcher(f.Client(t), nil, 1)
+	var prs Items
+	users := make(Users)
+	start := time.Now()
+	if err := fetcher.GetPRs(context.Background(), "o", "r", "all", nil, &prs, &users); err != nil {
+		t.Fatal(err)
+	}
+	if len(prs) != 4 {
+		t.Errorf("got %d PRs, want 4", len(prs))
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Errorf("waited %s for a rate limit which was already reset", time.Since(start))
+	}
+}
+
+func TestRateLimitExceeded(t *testing.T) {
+	defer fastRetries()()
+	f := newFakeRepo()
+	defer f.Close()
+	// The reset time is reported in seconds
+	reset := time.Now().Truncate(time.Second).Add(2 * time.Second)
+	f.SetRateLimit(3, reset)
+
+	// Requests in flight fail once the limit is used up and are
+	// retried after the reset
+	fetcher := NewFetcher(f.Client(t), nil, 4)
+	users := make(Users)
+	prs, issues := fetcher.GetItems(context.Background(), []string{"o/r"}, "all", nil, &users)
+	if len(prs) != 4 || len(issues) != 2 {
+		t.Errorf("got %d PRs and %d Issues, want 4 and 2", len(prs), len(issues))
+	}
+	if incomplete := fetcher.Incomplete(); len(incomplete) != 0 {
+		t.Errorf("unexpected incomplete items: %s: %v", incomplete[0].ID, incomplete[0].Err)
+	}
+	if time.Now().Before(reset) {
+		t.Errorf("did not wait for the rate limit to be reset")
+	}
+}
+
+// fastRetries makes retries immediate until the returned function is called
+func fastRetries() func() {
+	backoff, margin, secondary := retryBackoff, rateLimitMargin, secondaryRateLimitWait
+	retryBackoff, rateLimitMargin, secondaryRateLimitWait = time.Millisecond, 10*time.Millisecond, 10*time.Millisecond
+	return func() { retryBackoff, rateLimitMargin, secondaryRateLimitWait = backoff, margin, secondary }
+}
+
+func TestRetryServerError(t *testing.T) {
+	defer fastRetries()()
+	f := newFakeRepo()
+	defer f.Close()
+	f.Fail("/repos/o/r/pulls", http.StatusBadGateway, 2)
+	f.FailAbuse("/repos/o/r/pulls/1/reviews", 0, 1)
+	f.FailSecondary("/repos/o/r/pulls/3/reviews", 0, 2)
+
+	fetcher := NewFetcher(f.Client(t), nil, 1)
+	var prs Items
+	users := make(Users)
+	if err := fetcher.GetPRs(context.Background(), "o", "r", "all", nil, &prs, &users); err != nil {
+		t.Fatal(err)
+	}
+	if len(prs) != 4 {
+		t.Errorf("got %d PRs, want 4", len(prs))
+	}
+	if incomplete := fetcher.Incomplete(); len(incomplete) != 0 {
+		t.Errorf("unexpected incomplete items: %v", incomplete[0].Err)
+	}
+}
+
+func TestRetrySecondaryRateLimit(t *testing.T) {
+	defer fastRetries()()
+	f := newFakeRepo()
+	defer f.Close()
+	// A 429 asking to retry later
+	retryAfter := make(http.Header)
+	retryAfter.Set("Retry-After", "0")
+	f.FailSecondaryWith("/repos/o/r/pulls", http.StatusTooManyRequests, retryAfter, 1)
+	// A 403 with the reset time instead of Retry-After
+	reset := make(http.Header)
+	reset.Set("X-RateLimit-Remaining", "0")
+	reset.Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Unix(), 10))
+	f.FailSecondaryWith("/repos/o/r/pulls/1/reviews", http.StatusForbidden, reset, 1)
+	// A 403 with neither
+	f.FailSecondaryWith("/repos/o/r/pulls/3/reviews", http.StatusForbidden, make(http.Header), 2)
+
+	fetcher := NewFetcher(f.Client(t), nil, 1)
+	var prs Items
+	users := make(Users)
+	if err := fetcher.GetPRs(context.Background(), "o", "r", "all", nil, &prs, &users); err != nil {
+		t.Fatal(err)
+	}
+	if len(prs) != 4 {
+		t.Errorf("got %d PRs, want 4", len(prs))
+	}
+	if incomplete := fetcher.Incomplete(); len(incomplete) != 0 {
+		t.Errorf("unexpected incomplete items: %v", incomplete[0].Err)
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	defer fastRetries()()
+	f := newFakeRepo()
+	defer f.Close()
+	f.Fail("/repos/o/r/pulls", http.StatusInternalServerError, -1)
+
+	fetcher := NewFetcher(f.Client(t), nil, 1)
+	var prs Items
+	users := make(Users)
+	if err := fetcher.GetPRs(context.Background(), "o", "r", "all", nil, &prs, &users); err == nil {
+		t.Fatal("expected an error")
+	}
+	if n := len(f.Requests()); n != maxRetries+1 {
+		t.Errorf("got %d requests, want %d", n, maxRetries+1)
+	}
+
+	// Client errors are not retried
+	f = newFakeRepo()
+	defer f.Close()
+	f.Fail("/repos/o/r/pulls", http.StatusNotFound, -1)
+	fetcher = NewFetcher(f.Client(t), nil, 1)
+	if err := fetcher.GetPRs(context.Background(), "o", "r", "all", nil, &prs, &users); err == nil {
+		t.Fatal("expected an error")
+	}
+	if n := len(f.Requests()); n != 1 {
+		t.Errorf("got %d requests, want 1", n)
+	}
+
+	// Neither are 403s without Retry-After
+	f = newFakeRepo()
+	defer f.Close()
+	f.Fail("/repos/o/r/pulls", http.StatusForbidden, -1)
+	fetcher = NewFetcher(f.Client(t), nil, 1)
+	if err := fetcher.GetPRs(context.Background(), "o", "r", "all", nil, &prs, &users); err == nil {
+		t.Fatal("expected an error")
+	}
+	if n := len(f.Requests()); n != 1 {
+		t.Errorf("got %d requests, want 1", n)
+	}
+}
+
+func TestIncompleteItems(t *testing.T) {
+	defer fastRetries()()
+	f := newFakeRepo()
+	defer f.Close()
+	f.Fail("/repos/o/r/pulls/1/reviews", http.StatusInternalServerError, -1)
+	f.Fail("/repos/o/r/issues/2/comments", http.StatusForbidden, -1)
+
+	dir, err := ioutil.TempDir("", "gh-report-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cache, err := NewCache(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	period, _ := NewPeriodFromMonth("2018-03")
+	fetcher := NewFetcher(f.Client(t), cache, 2)
+	users := make(Users)
+	prs, issues := fetcher.GetItems(context.Background(), []string{"o/r"}, "all", &period.Start, &users)
+	if len(prs) != 3 || len(issues) != 2 {
+		t.Errorf("got %d PRs and %d Issues, want 3 and 2", len(prs), len(issues))
+	}
+
+	var got []string
+	for _, fe := range fetcher.Incomplete() {
+		got = append(got, fe.ID)
+		if !strings.Contains(fe.Err.Error(), "reviews:") && !strings.Contains(fe.Err.Error(), "comments:") {
+			t.Errorf("%s: error does not name the missing part: %v", fe.ID, fe.Err)
+		}
+	}
+	if strings.Join(got, " ") != "o/r#1 o/r#2" {
+		t.Errorf("got incomplete %v, want [o/r#1 o/r#2]", got)
+	}
+
+	// Incomplete Items are not cached
+	for _, i := range append(prs, issues...) {
+		cached := cache.Get(i.Repo, i.Number, i.UpdatedAt, &users) != nil
+		if want := i.ID != "o/r#1" && i.ID != "o/r#2"; cached != want {
+			t.Errorf("%s cached: %t, want %t", i.ID, cached, want)
+		}
+	}
+}
+
+func TestReportsGolden(t *testing.T) {
+	f := newFakeRepo()
+	defer f.Close()
+
+	period, _ := NewPeriodFromMonth("2018-03")
+	fetcher := NewFetcher(f.Client(t), nil, 2)
+	users := make(Users)
+	prs, issues := fetcher.GetItems(context.Background(), []string{"o/r"}, "all", &period.Start, &users)
+
+	for _, tc := range []struct {
+		name   string
+		report func() Report
+	}{
+		{"repo-report", func() Report { return NewRepoReport("https://github.com/", []string{"o/r"}, period, prs, issues) }},
+		{"user-report", func() Report {
+			return NewUserReport("https://github.com/", []string{"o/r"}, period, "alice", prs, issues)
+		}},
+	} {
+		for _, format := range []string{FormatMarkdown, FormatJSON, FormatHTML} {
+			var buf bytes.Buffer
+			if err := Render(&buf, tc.report(), format); err != nil {
+				t.Fatalf("%s %s: %v", tc.name, format, err)
+			}
+			ext := map[string]string{FormatMarkdown: "md", FormatJSON: "json", FormatHTML: "html"}[format]
+			checkGolden(t, tc.name+"."+ext, buf.Bytes())
+			if format == FormatMarkdown {
+				checkLinks(t, tc.name, buf.String())
+			}
+		}
+	}
+}
+
+var (
+	linkDefinition = regexp.MustCompile(`(?m)^\[([^\]]+)\]: `)
+	linkReference  = regexp.MustCompile(`\[([^\]]+)\]`)
+)
+
+// checkLinks checks that a markdown document defines every link it
+// refers to exactly once
+func checkLinks(t *testing.T, name, md string) {
+	defined := make(map[string]bool)
+	for _, m := range linkDefinition.FindAllStringSubmatch(md, -1) {
+		if defined[m[1]] {
+			t.Errorf("%s: link [%s] is defined more than once", name, m[1])
+		}
+		defined[m[1]] = true
+	}
+	for _, m := range linkReference.FindAllStringSubmatch(md, -1) {
+		if !defined[m[1]] {
+			t.Errorf("%s: link [%s] is not defined", name, m[1])
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// fixture is a recorded response of the GitHub API
+type fixture struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// fixturePath returns the file a response to a request is recorded in.
+// Requests are identified by method and URL.
+func fixturePath(dir string, req *http.Request) string {
+	sum := sha256.Sum256([]byte(req.Method + " " + req.URL.String()))
+	return filepath.Join(dir, fmt.Sprintf("%x.json", sum[:8]))
+}
+
+// Recorder is a http.RoundTripper which records all responses in a
+// directory in a form the Replayer can serve them back.
+type Recorder struct {
+	Dir string
+	// Transport makes the actual requests
+	Transport http.RoundTripper
+}
+
+// NewRecorder creates a Recorder writing to dir
+func NewRecorder(dir string, transport http.RoundTripper) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &Recorder{Dir: dir, Transport: transport}, nil
+}
+
+// RoundTrip makes the request and records the response
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := r.Transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	data, err := json.MarshalIndent(&fixture{
+		Method:     req.Method,
+		URL:        req.URL.String(),
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       body,
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	// Requests may run concurrently, so write to a unique file first
+	tmp, err := ioutil.TempFile(r.Dir, "tmp")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+	debugf("  Recorded: %s %s\n", req.Method, req.URL)
+	return resp, os.Rename(tmp.Name(), fixturePath(r.Dir, req))
+}
+
+// Replayer is a http.RoundTripper which serves the responses recorded
+// by a Recorder. It fails requests which were not recorded.
+type Replayer struct {
+	Dir string
+}
+
+// RoundTrip returns the recorded response to the request
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	data, err := ioutil.ReadFile(fixturePath(r.Dir, req))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no recorded response for %s %s", req.Method, req.URL)
+	}
+	if err != nil {
+		return nil, err
+	}
+	f := &fixture{}
+	if err := json.Unmarshal(data, f); err != nil {
+		return nil, fmt.Errorf("corrupt recorded response for %s %s: %v", req.Method, req.URL, err)
+	}
+	debugf("  Replayed: %s %s\n", req.Method, req.URL)
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", f.StatusCode, http.StatusText(f.StatusCode)),
+		StatusCode:    f.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        f.Header,
+		Body:          ioutil.NopCloser(bytes.NewReader(f.Body)),
+		ContentLength: int64(len(f.Body)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRecordReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gh-report-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	period, _ := NewPeriodFromMonth("2018-03")
+	report := func(tc *http.Client, baseURL string) []byte {
+		client, err := newClient(tc, baseURL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fetcher := NewFetcher(client, nil, 2)
+		users := make(Users)
+		prs, issues := fetcher.GetItems(context.Background(), []string{"o/r"}, "all", &period.Start, &users)
+		if incomplete := fetcher.Incomplete(); len(incomplete) != 0 {
+			t.Fatalf("could not fetch %s: %v", incomplete[0].ID, incomplete[0].Err)
+		}
+		var buf bytes.Buffer
+		r := NewRepoReport("https://github.com/", []string{"o/r"}, period, prs, issues)
+		if err := Render(&buf, r, FormatMarkdown); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+
+	f := newFakeRepo()
+	recorder, err := NewRecorder(dir, f.Server.Client().Transport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorded := report(&http.Client{Transport: recorder}, f.URL)
+	f.Close()
+
+	// The replay does not need the server
+	replayed := report(&http.Client{Transport: &Replayer{Dir: dir}}, f.URL)
+	golden, err := ioutil.ReadFile(filepath.Join("testdata", "repo-report.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(recorded, golden) {
+		t.Errorf("recorded report differs from the golden file:\n%s", recorded)
+	}
+	if !bytes.Equal(replayed, golden) {
+		t.Errorf("replayed report differs from the golden file:\n%s", replayed)
+	}
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"strings"
+)
+
+// stringList is a flag.Value which collects a list of strings. The
+// flag may be repeated and each value may be a comma separated list.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+// Set adds the comma separated values of s to the list
+func (l *stringList) Set(s string) error {
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*l = append(*l, v)
+		}
+	}
+	return nil
+}
@@ -3,18 +3,115 @@ package main
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/url"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/go-github/github"
 )
 
+// Retries of failed requests start after retryBackoff, doubling the
+// wait each time up to maxRetries times. Requests hitting the rate limit
+// are retried rateLimitMargin after the limit is reset, and those
+// hitting the secondary rate limit without saying how long to wait
+// after secondaryRateLimitWait.
+var (
+	retryBackoff           = 2 * time.Second
+	maxRetries             = 5
+	rateLimitMargin        = 5 * time.Second
+	secondaryRateLimitWait = time.Minute
+)
+
+// retryWait returns how long to wait before retrying a request which
+// failed with err for the n-th time. Server errors and rate limits are
+// retried.
+func retryWait(err error, n int) (time.Duration, bool) {
+	if n >= maxRetries {
+		return 0, false
+	}
+	wait := retryBackoff << uint(n)
+	switch e := err.(type) {
+	case *github.RateLimitError:
+		// Once the limit is used up the client fails all requests
+		// without making them until the reset time
+		wait = time.Until(e.Rate.Reset.Time) + rateLimitMargin
+		if wait < rateLimitMargin {
+			wait = rateLimitMargin
+		}
+		return wait, true
+	case *github.AbuseRateLimitError:
+		if e.RetryAfter != nil {
+			wait = *e.RetryAfter
+		}
+		return wait, true
+	case *github.ErrorResponse:
+		if e.Response == nil {
+			return 0, false
+		}
+		// The client only recognises secondary rate limits by an
+		// outdated documentation URL
+		switch status := e.Response.StatusCode; {
+		case status == http.StatusForbidden || status == http.StatusTooManyRequests:
+			return secondaryRateLimitWaitFor(e)
+		case status >= 500:
+			return wait, true
+		}
+	}
+	return 0, false
+}
+
+// secondaryRateLimitWaitFor returns how long to wait before retrying a
+// request GitHub rejected with a 403 or 429. GitHub either asks to
+// retry after some seconds, reports the time the limit is reset, or
+// only says that the secondary rate limit was hit. Other 403s are not
+// retried.
+func secondaryRateLimitWaitFor(e *github.ErrorResponse) (time.Duration, bool) {
+	header := e.Response.Header
+	if secs, err := strconv.Atoi(header.Get("Retry-After")); err == nil {
+		return time.Duration(secs) * time.Second, true
+	}
+	if header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			wait := time.Until(time.Unix(reset, 0)) + rateLimitMargin
+			if wait < rateLimitMargin {
+				wait = rateLimitMargin
+			}
+			return wait, true
+		}
+	}
+	if e.Response.StatusCode == http.StatusTooManyRequests ||
+		strings.Contains(strings.ToLower(e.Message), "secondary rate limit") {
+		return secondaryRateLimitWait, true
+	}
+	return 0, false
+}
+
+// doRetry performs a GitHub API operation, retrying it with exponential
+// backoff if it fails temporarily
+func doRetry(op func() (*github.Response, error)) (*github.Response, error) {
+	for n := 0; ; n++ {
+		r, err := op()
+		if err == nil {
+			return r, nil
+		}
+		wait, ok := retryWait(err, n)
+		if !ok {
+			return r, err
+		}
+		warnf("Retrying in %s: %v\n", wait, err)
+		time.Sleep(wait)
+	}
+}
+
 // Perform a GitHub API List operation considering paging and rate limiting
 // If op() returns nil, we are done too
 func doListOp(op func(page int) (*github.Response, error)) error {
 	for page := 1; page != 0; {
-		r, err := op(page)
+		r, err := doRetry(func() (*github.Response, error) { return op(page) })
 		if err != nil {
 			return err
 		}
@@ -27,22 +124,43 @@ func doListOp(op func(page int) (*github.Response, error)) error {
 		// Handle rate limiting
 		if r.Remaining == 0 {
 			warnf("No more request this period. Limit %d reset at %s\n", r.Limit, r.Reset)
-			warnf("Sleep for %s\n", time.Until(r.Reset.Time)+(5*time.Second))
-			time.Sleep(time.Until(r.Reset.Time) + (5 * time.Second))
+			warnf("Sleep for %s\n", time.Until(r.Reset.Time)+rateLimitMargin)
+			time.Sleep(time.Until(r.Reset.Time) + rateLimitMargin)
 		}
 	}
 	return nil
 }
 
+// WebURL returns the URL of the web interface of the GitHub instance
+// the client talks to, with a trailing slash.
+func WebURL(client *github.Client) string {
+	u := *client.BaseURL
+	if u.Host == "api.github.com" {
+		return "https://github.com/"
+	}
+	// GitHub Enterprise serves the API under /api/v3/
+	u.Path = strings.TrimSuffix(strings.TrimSuffix(u.Path, "/"), "/api/v3") + "/"
+	u.RawQuery = ""
+	return u.String()
+}
+
 // User is a structure with information about a user
 type User struct {
-	ID  string
-	URL string
+	ID        string
+	URL       string
+	AvatarURL string
+	// Bot is set for GitHub Apps and other bot accounts
+	Bot bool
 }
 
 // NewUser create a new User
 func NewUser(u *github.User) *User {
-	return &User{ID: *u.Login, URL: *u.HTMLURL}
+	return &User{
+		ID:        *u.Login,
+		URL:       *u.HTMLURL,
+		AvatarURL: u.GetAvatarURL(),
+		Bot:       u.GetType() == "Bot" || strings.HasSuffix(*u.Login, "[bot]"),
+	}
 }
 
 func (u *User) String() string {
@@ -57,9 +175,15 @@ func (u *User) Link() string {
 // Users is a structure to store information about users
 type Users map[string]*User
 
+// usersLock protects Users maps which are updated while fetching
+// Items concurrently.
+var usersLock sync.Mutex
+
 // Add adds a GH user to a map of Users if the user does not exist
 func (users Users) Add(u *github.User) *User {
 	debug2f("  Add user: %s\n", *u.Login)
+	usersLock.Lock()
+	defer usersLock.Unlock()
 	if user, ok := users[*u.Login]; ok {
 		return user
 	}
@@ -69,12 +193,29 @@ func (users Users) Add(u *github.User) *User {
 	return user
 }
 
-// Links returns a string with markdown links to all users
+// AddUser adds a User to a map of Users if the user does not exist.
+// It returns the User stored in the map.
+func (users Users) AddUser(u *User) *User {
+	usersLock.Lock()
+	defer usersLock.Unlock()
+	if user, ok := users[u.ID]; ok {
+		return user
+	}
+	users[u.ID] = u
+	return u
+}
+
+// Links returns a string with markdown links to all users sorted by ID
 func (users Users) Links() string {
+	ids := make([]string, 0, len(users))
+	for id := range users {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
 	var ret string
 	var r string
-	for _, user := range users {
-		r += ret + user.Link()
+	for _, id := range ids {
+		r += ret + users[id].Link()
 		if ret == "" {
 			ret = "\n"
 		}
@@ -82,15 +223,62 @@ func (users Users) Links() string {
 	return r
 }
 
+// Kinds of Comments
+const (
+	// CommentIssue is a comment on an Issue
+	CommentIssue = "comment"
+	// CommentReview is a review of a PR. Its State is the review state,
+	// e.g. APPROVED, CHANGES_REQUESTED or COMMENTED.
+	CommentReview = "review"
+	// CommentReviewComment is a comment on a line of a PR
+	CommentReviewComment = "review_comment"
+)
+
 // Comment represents a Comment on a Issue or PR
 type Comment struct {
 	CreatedAt time.Time
 	User      *User
+	Kind      string
+	// State is only set for reviews
+	State string
+	// Association of the user with the repository, not set for reviews
+	AuthorAssociation string
+}
+
+// The vendored client does not decode the author association of Issues
+// and comments, so they are listed with these types instead.
+type (
+	listedIssue struct {
+		github.Issue
+		AuthorAssociation string `json:"author_association"`
+	}
+	listedIssueComment struct {
+		github.IssueComment
+		AuthorAssociation string `json:"author_association"`
+	}
+	listedPRComment struct {
+		github.PullRequestComment
+		AuthorAssociation string `json:"author_association"`
+	}
+)
+
+// listPage gets a page of the list at the API path into v, like the
+// List methods of the client do
+func (f *Fetcher) listPage(ctx context.Context, path string, opts url.Values, page int, v interface{}) (*github.Response, error) {
+	if opts == nil {
+		opts = make(url.Values)
+	}
+	opts.Set("page", strconv.Itoa(page))
+	req, err := f.Client.NewRequest("GET", path+"?"+opts.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return f.Client.Do(ctx, req, v)
 }
 
 // NewCommentFromPR creates a Comment from a GH pull request comment.
-func NewCommentFromPR(c *github.PullRequestComment, users *Users) *Comment {
-	comment := &Comment{CreatedAt: *c.CreatedAt}
+func NewCommentFromPR(c *listedPRComment, users *Users) *Comment {
+	comment := &Comment{CreatedAt: *c.CreatedAt, Kind: CommentReviewComment, AuthorAssociation: c.AuthorAssociation}
 	if c.User != nil {
 		comment.User = users.Add(c.User)
 	}
@@ -98,12 +286,15 @@ func NewCommentFromPR(c *github.PullRequestComment, users *Users) *Comment {
 }
 
 func (c *Comment) String() string {
-	return fmt.Sprintf("%s %s", c.CreatedAt, c.User.String())
+	if c.State != "" {
+		return fmt.Sprintf("%s %s %s %s", c.CreatedAt, c.User.String(), c.Kind, c.State)
+	}
+	return fmt.Sprintf("%s %s %s", c.CreatedAt, c.User.String(), c.Kind)
 }
 
 // NewCommentFromReview creates a Comment from a GH pull request review.
 func NewCommentFromReview(c *github.PullRequestReview, users *Users) *Comment {
-	comment := &Comment{CreatedAt: *c.SubmittedAt}
+	comment := &Comment{CreatedAt: *c.SubmittedAt, Kind: CommentReview, State: c.GetState()}
 	if c.User != nil {
 		comment.User = users.Add(c.User)
 	}
@@ -111,8 +302,8 @@ func NewCommentFromReview(c *github.PullRequestReview, users *Users) *Comment {
 }
 
 // NewCommentFromIssue creates a Comment from a GH issue comment.
-func NewCommentFromIssue(c *github.IssueComment, users *Users) *Comment {
-	comment := &Comment{CreatedAt: *c.CreatedAt}
+func NewCommentFromIssue(c *listedIssueComment, users *Users) *Comment {
+	comment := &Comment{CreatedAt: *c.CreatedAt, Kind: CommentIssue, AuthorAssociation: c.AuthorAssociation}
 	if c.User != nil {
 		comment.User = users.Add(c.User)
 	}
@@ -129,18 +320,28 @@ type Item struct {
 	Title     string
 	URL       string
 	CreatedBy *User
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	ClosedAt  time.Time
-	Comments  []*Comment
+	// Association of the creator with the repository
+	AuthorAssociation string
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	ClosedAt          time.Time
+	Labels            []string
+	Comments          []*Comment
 	// PR specific fields
-	Merged   bool
-	MergedAt time.Time
-	MergedBy *User
+	Merged         bool
+	MergedAt       time.Time
+	MergedBy       *User
+	MergeCommitSHA string
+	Additions      int
+	Deletions      int
+	ChangedFiles   int
 }
 
 // NewItemFromPR creates an new Item and extracts some additional information
-func NewItemFromPR(ctx context.Context, client *github.Client, pr *github.PullRequest, repo string, users *Users) *Item {
+// or takes it from the cache if the PR has not been updated since. If some
+// of the information could not be fetched, the incomplete Item is returned
+// with an error.
+func (f *Fetcher) NewItemFromPR(ctx context.Context, pr *github.PullRequest, repo string, users *Users) (*Item, error) {
 	i := &Item{PR: true,
 		ID:        fmt.Sprintf("%s#%d", repo, *pr.Number),
 		Repo:      repo,
@@ -149,6 +350,8 @@ func NewItemFromPR(ctx context.Context, client *github.Client, pr *github.PullRe
 		Title:     *pr.Title,
 		URL:       *pr.HTMLURL,
 		CreatedAt: *pr.CreatedAt,
+		Labels:    []string{},
+		Comments:  []*Comment{},
 	}
 
 	if pr.User != nil {
@@ -171,15 +374,52 @@ func NewItemFromPR(ctx context.Context, client *github.Client, pr *github.PullRe
 		// Sometimes pr.Merged does not seem to be set.
 		i.Merged = true
 	}
+	if pr.AuthorAssociation != nil {
+		i.AuthorAssociation = *pr.AuthorAssociation
+	}
+	if pr.MergeCommitSHA != nil {
+		i.MergeCommitSHA = *pr.MergeCommitSHA
+	}
+	if cached := f.Cache.Get(repo, i.Number, i.UpdatedAt, users); cached != nil {
+		return cached, nil
+	}
 
 	t := strings.SplitN(repo, "/", 2)
+	var errs fetchErrors
+
+	// Listed PRs do not contain the size
+	if pr.Additions == nil {
+		_, err := doRetry(func() (*github.Response, error) {
+			ghPR, resp, err := f.Client.PullRequests.Get(ctx, t[0], t[1], i.Number)
+			if err == nil {
+				pr = ghPR
+			}
+			return resp, err
+		})
+		errs.add("details", err)
+	}
+	i.Additions = pr.GetAdditions()
+	i.Deletions = pr.GetDeletions()
+	i.ChangedFiles = pr.GetChangedFiles()
+
+	// Comments on the conversation of a PR are Issue comments
+	errs.add("conversation", doListOp(func(page int) (*github.Response, error) {
+		var ghComments []*listedIssueComment
+		resp, err := f.listPage(ctx, fmt.Sprintf("repos/%s/%s/issues/%d/comments", t[0], t[1], i.Number), nil, page, &ghComments)
+		if err != nil {
+			return nil, err
+		}
+		for _, ghComment := range ghComments {
+			c := NewCommentFromIssue(ghComment, users)
+			i.Comments = append(i.Comments, c)
+		}
+		return resp, nil
+	}))
 
-	doListOp(func(page int) (*github.Response, error) {
-		commentOpts := &github.PullRequestListCommentsOptions{}
-		commentOpts.ListOptions.Page = page
-		ghComments, resp, err := client.PullRequests.ListComments(ctx, t[0], t[1], i.Number, commentOpts)
+	errs.add("comments", doListOp(func(page int) (*github.Response, error) {
+		var ghComments []*listedPRComment
+		resp, err := f.listPage(ctx, fmt.Sprintf("repos/%s/%s/pulls/%d/comments", t[0], t[1], i.Number), nil, page, &ghComments)
 		if err != nil {
-			fmt.Println("Error getting comments for %s", i.ID)
 			return nil, err
 		}
 		for _, ghComment := range ghComments {
@@ -187,13 +427,12 @@ func NewItemFromPR(ctx context.Context, client *github.Client, pr *github.PullRe
 			i.Comments = append(i.Comments, c)
 		}
 		return resp, nil
-	})
+	}))
 
-	doListOp(func(page int) (*github.Response, error) {
+	errs.add("reviews", doListOp(func(page int) (*github.Response, error) {
 		reviewOpts := &github.ListOptions{Page: page}
-		ghReviews, resp, err := client.PullRequests.ListReviews(ctx, t[0], t[1], i.Number, reviewOpts)
+		ghReviews, resp, err := f.Client.PullRequests.ListReviews(ctx, t[0], t[1], i.Number, reviewOpts)
 		if err != nil {
-			fmt.Println("Error getting review comments for %s", i.ID)
 			return nil, err
 		}
 		for _, ghReview := range ghReviews {
@@ -205,20 +444,45 @@ func NewItemFromPR(ctx context.Context, client *github.Client, pr *github.PullRe
 			i.Comments = append(i.Comments, c)
 		}
 		return resp, nil
-	})
-	return i
+	}))
+
+	// The PR returned by the API does not contain the labels
+	errs.add("labels", doListOp(func(page int) (*github.Response, error) {
+		labelOpts := &github.ListOptions{Page: page}
+		ghLabels, resp, err := f.Client.Issues.ListLabelsByIssue(ctx, t[0], t[1], i.Number, labelOpts)
+		if err != nil {
+			return nil, err
+		}
+		for _, ghLabel := range ghLabels {
+			i.Labels = append(i.Labels, ghLabel.GetName())
+		}
+		return resp, nil
+	}))
+	if len(errs) > 0 {
+		// Do not cache incomplete Items
+		return i, errs
+	}
+	if err := f.Cache.Put(i); err != nil {
+		warnf("Error caching %s: %v\n", i.ID, err)
+	}
+	return i, nil
 }
 
 // NewItemFromIssue creates an new Item and extracts some additional information
-func NewItemFromIssue(ctx context.Context, client *github.Client, issue *github.Issue, repo string, users *Users) *Item {
+// or takes it from the cache if the issue has not been updated since. If the
+// comments could not be fetched, the incomplete Item is returned with an error.
+func (f *Fetcher) NewItemFromIssue(ctx context.Context, issue *listedIssue, repo string, users *Users) (*Item, error) {
 	i := &Item{PR: false,
-		ID:        fmt.Sprintf("%s#%d", repo, *issue.Number),
-		Repo:      repo,
-		Number:    *issue.Number,
-		State:     *issue.State,
-		Title:     *issue.Title,
-		URL:       *issue.HTMLURL,
-		CreatedAt: *issue.CreatedAt,
+		ID:                fmt.Sprintf("%s#%d", repo, *issue.Number),
+		Repo:              repo,
+		Number:            *issue.Number,
+		State:             *issue.State,
+		Title:             *issue.Title,
+		URL:               *issue.HTMLURL,
+		AuthorAssociation: issue.AuthorAssociation,
+		CreatedAt:         *issue.CreatedAt,
+		Labels:            []string{},
+		Comments:          []*Comment{},
 	}
 
 	if issue.User != nil {
@@ -230,14 +494,21 @@ func NewItemFromIssue(ctx context.Context, client *github.Client, issue *github.
 	if issue.ClosedAt != nil {
 		i.ClosedAt = *issue.ClosedAt
 	}
+	for _, l := range issue.Labels {
+		if l.Name != nil {
+			i.Labels = append(i.Labels, *l.Name)
+		}
+	}
+	if cached := f.Cache.Get(repo, i.Number, i.UpdatedAt, users); cached != nil {
+		return cached, nil
+	}
 
 	t := strings.SplitN(repo, "/", 2)
-	doListOp(func(page int) (*github.Response, error) {
-		commentOpts := &github.IssueListCommentsOptions{}
-		commentOpts.ListOptions.Page = page
-		ghComments, resp, err := client.Issues.ListComments(ctx, t[0], t[1], i.Number, commentOpts)
+	var errs fetchErrors
+	errs.add("comments", doListOp(func(page int) (*github.Response, error) {
+		var ghComments []*listedIssueComment
+		resp, err := f.listPage(ctx, fmt.Sprintf("repos/%s/%s/issues/%d/comments", t[0], t[1], i.Number), nil, page, &ghComments)
 		if err != nil {
-			fmt.Println("Error getting comments for %s", i.ID)
 			return nil, err
 		}
 		for _, ghComment := range ghComments {
@@ -245,26 +516,56 @@ func NewItemFromIssue(ctx context.Context, client *github.Client, issue *github.
 			i.Comments = append(i.Comments, c)
 		}
 		return resp, nil
-	})
-	return i
+	}))
+	if len(errs) > 0 {
+		return i, errs
+	}
+	if err := f.Cache.Put(i); err != nil {
+		warnf("Error caching %s: %v\n", i.ID, err)
+	}
+	return i, nil
 }
 
-func (i *Item) String() string {
-	ret := fmt.Sprintf("%s ([%s] %s", i.Title, i.ID, i.CreatedBy)
-
+// shareUsers replaces the Users of an Item read from disk with the
+// ones in users, adding them if needed, so Users are shared between Items
+func (i *Item) shareUsers(users *Users) {
+	if i.CreatedBy != nil {
+		i.CreatedBy = users.AddUser(i.CreatedBy)
+	}
 	if i.MergedBy != nil {
-		ret += " " + i.MergedBy.String()
+		i.MergedBy = users.AddUser(i.MergedBy)
+	}
+	for _, comment := range i.Comments {
+		if comment.User != nil {
+			comment.User = users.AddUser(comment.User)
+		}
 	}
+}
 
-	// Make the list of contributors unique
-	set := make(map[string]struct{})
-	for _, c := range i.Comments {
-		if c.User != i.CreatedBy && c.User != i.MergedBy {
-			set[c.User.String()] = struct{}{}
+// Participants returns the unique users involved with the Item: The
+// creator, the user who merged it and everyone who commented on it in
+// the order of their first comment.
+func (i *Item) Participants() []*User {
+	var users []*User
+	seen := make(map[string]bool)
+	add := func(u *User) {
+		if u != nil && !seen[u.ID] {
+			seen[u.ID] = true
+			users = append(users, u)
 		}
 	}
-	for u := range set {
-		ret += " " + u
+	add(i.CreatedBy)
+	add(i.MergedBy)
+	for _, c := range i.Comments {
+		add(c.User)
+	}
+	return users
+}
+
+func (i *Item) String() string {
+	ret := fmt.Sprintf("%s ([%s]", i.Title, i.ID)
+	for _, u := range i.Participants() {
+		ret += " " + u.String()
 	}
 	return ret + ")"
 }
@@ -277,7 +578,9 @@ func (i *Item) Dump() string {
 	ret += fmt.Sprintf("\n  URL:       %s", i.URL)
 	ret += fmt.Sprintf("\n  Created:   %s %s", i.CreatedBy.String(), i.CreatedAt)
 	ret += fmt.Sprintf("\n  Updated:   %s", i.UpdatedAt)
+	ret += fmt.Sprintf("\n  Labels:    %s", strings.Join(i.Labels, ", "))
 	if i.PR {
+		ret += fmt.Sprintf("\n  Size:      +%d -%d in %d files", i.Additions, i.Deletions, i.ChangedFiles)
 		if i.Merged {
 			if i.MergedBy != nil {
 				ret += fmt.Sprintf("\n  Merged:    %s %s", i.MergedBy.String(), i.ClosedAt)
@@ -341,58 +644,130 @@ func (items Items) Links() string {
 	return r
 }
 
-// GetPRs gets a list of PRs and users involved since a given time
-func GetPRs(ctx context.Context, client *github.Client, owner, repo string, since *time.Time, prs *Items, users *Users) error {
+// linkSet collects the Items and users a markdown document refers to,
+// so each of them is only linked once
+type linkSet struct {
+	items map[string]*Item
+	users Users
+}
+
+func newLinkSet() *linkSet {
+	return &linkSet{items: make(map[string]*Item), users: make(Users)}
+}
+
+// addItems adds Items and all users involved with them
+func (l *linkSet) addItems(lists ...Items) {
+	for _, items := range lists {
+		for _, i := range items {
+			l.items[i.ID] = i
+			l.addUsers(i.Participants()...)
+		}
+	}
+}
+
+// addUsers adds users, ignoring nil ones
+func (l *linkSet) addUsers(users ...*User) {
+	for _, u := range users {
+		if u != nil {
+			l.users[u.ID] = u
+		}
+	}
+}
+
+// String returns the links to the Items, sorted like Items.String,
+// followed by the links to the users
+func (l *linkSet) String() string {
+	items := make(Items, 0, len(l.items))
+	for _, i := range l.items {
+		items = append(items, i)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Repo != items[j].Repo {
+			return items[i].Repo < items[j].Repo
+		}
+		return items[i].Number < items[j].Number
+	})
+	if len(items) == 0 {
+		return l.users.Links()
+	}
+	return items.Links() + "\n" + l.users.Links()
+}
+
+// GetPRs gets a list of PRs in a state (open, closed or all) and users involved since a given time
+func (f *Fetcher) GetPRs(ctx context.Context, owner, repo, state string, since *time.Time, prs *Items, users *Users) error {
 	err := doListOp(func(page int) (*github.Response, error) {
-		prOpts := &github.PullRequestListOptions{State: "all", Sort: "updated", Direction: "desc"}
+		prOpts := &github.PullRequestListOptions{State: state, Sort: "updated", Direction: "desc"}
 		prOpts.ListOptions.Page = page
-		ghPRs, resp, err := client.PullRequests.List(ctx, owner, repo, prOpts)
+		ghPRs, resp, err := f.Client.PullRequests.List(ctx, owner, repo, prOpts)
 		if err != nil {
-			warnf("Error getting PRs for %s: %v", repo, err)
 			return nil, err
 		}
+		var todo []*github.PullRequest
+		var done bool
 		for _, ghPR := range ghPRs {
-			infof("Handle PR: %s/%s#%d %s\n", owner, repo, *ghPR.Number, *ghPR.Title)
-			debug2f("%+v\n\n", ghPR)
-			pr := NewItemFromPR(ctx, client, ghPR, fmt.Sprintf("%s/%s", owner, repo), users)
 			// The List options for PRs does not have a Since field (like Issues),
 			// so check here when to break.
-			if since != nil && pr.CreatedAt.Before(*since) && pr.UpdatedAt.Before(*since) && pr.ClosedAt.Before(*since) {
-				return nil, nil
+			if since != nil && allBefore(*since, ghPR.CreatedAt, ghPR.UpdatedAt, ghPR.ClosedAt) {
+				done = true
+				break
 			}
-			*prs = append(*prs, pr)
+			todo = append(todo, ghPR)
+		}
+		items := make(Items, len(todo))
+		f.forEach(len(todo), func(n int) {
+			ghPR := todo[n]
+			infof("Handle PR: %s/%s#%d %s\n", owner, repo, *ghPR.Number, *ghPR.Title)
+			debug2f("%+v\n\n", ghPR)
+			var err error
+			items[n], err = f.NewItemFromPR(ctx, ghPR, fmt.Sprintf("%s/%s", owner, repo), users)
+			if err != nil {
+				f.addIncomplete(items[n].ID, err)
+			}
+		})
+		*prs = append(*prs, items...)
+		if done {
+			return nil, nil
 		}
 		return resp, nil
 	})
 	return err
 }
 
-// GetIssues gets a list of Issues and users involved since a given time
-func GetIssues(ctx context.Context, client *github.Client, owner, repo string, since *time.Time, issues *Items, users *Users) error {
+// GetIssues gets a list of Issues in a state (open, closed or all) and users involved since a given time
+func (f *Fetcher) GetIssues(ctx context.Context, owner, repo, state string, since *time.Time, issues *Items, users *Users) error {
 	err := doListOp(func(page int) (*github.Response, error) {
-		issueOpts := &github.IssueListByRepoOptions{
-			State:     "all",
-			Sort:      "updated",
-			Direction: "desc",
+		issueOpts := url.Values{
+			"state":     {state},
+			"sort":      {"updated"},
+			"direction": {"desc"},
 		}
 		if since != nil {
-			issueOpts.Since = *since
+			issueOpts.Set("since", since.Format(time.RFC3339))
 		}
-		issueOpts.ListOptions.Page = page
-		ghIssues, resp, err := client.Issues.ListByRepo(ctx, owner, repo, issueOpts)
+		var ghIssues []*listedIssue
+		resp, err := f.listPage(ctx, fmt.Sprintf("repos/%s/%s/issues", owner, repo), issueOpts, page, &ghIssues)
 		if err != nil {
-			warnf("Error getting issues for %s/%s: %v", owner, repo, err)
 			return nil, err
 		}
+		var todo []*listedIssue
 		for _, ghIssue := range ghIssues {
 			// Only handle proper issues
 			if !ghIssue.IsPullRequest() {
-				infof("Handle Issue: %s/%s#%d %s\n", owner, repo, *ghIssue.Number, *ghIssue.Title)
-				debug2f("%+v\n\n", ghIssue)
-				issue := NewItemFromIssue(ctx, client, ghIssue, fmt.Sprintf("%s/%s", owner, repo), users)
-				*issues = append(*issues, issue)
+				todo = append(todo, ghIssue)
 			}
 		}
+		items := make(Items, len(todo))
+		f.forEach(len(todo), func(n int) {
+			ghIssue := todo[n]
+			infof("Handle Issue: %s/%s#%d %s\n", owner, repo, *ghIssue.Number, *ghIssue.Title)
+			debug2f("%+v\n\n", ghIssue)
+			var err error
+			items[n], err = f.NewItemFromIssue(ctx, ghIssue, fmt.Sprintf("%s/%s", owner, repo), users)
+			if err != nil {
+				f.addIncomplete(items[n].ID, err)
+			}
+		})
+		*issues = append(*issues, items...)
 		return resp, nil
 	})
 	return err
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestItemsStringSorted(t *testing.T) {
+	a := &User{ID: "alice"}
+	b := &User{ID: "bob"}
+	items := Items{
+		{ID: "o/s#2", Repo: "o/s", Number: 2, Title: "Two", CreatedBy: a},
+		{ID: "o/r#10", Repo: "o/r", Number: 10, Title: "Ten", CreatedBy: b},
+		{ID: "o/r#9", Repo: "o/r", Number: 9, Title: "Nine", CreatedBy: a, MergedBy: b},
+	}
+	want := `
+- Nine ([o/r#9] [@alice] [@bob])
+- Ten ([o/r#10] [@bob])
+
+- Two ([o/s#2] [@alice])`
+	if got := items.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	// The Items are sorted in place
+	if items[0].ID != "o/r#9" || items[2].ID != "o/s#2" {
+		t.Errorf("items not sorted: %s %s %s", items[0].ID, items[1].ID, items[2].ID)
+	}
+}
+
+func TestParticipants(t *testing.T) {
+	a := &User{ID: "alice"}
+	b := &User{ID: "bob"}
+	c := &User{ID: "carol"}
+	i := &Item{CreatedBy: a, MergedBy: b, Comments: []*Comment{{User: c}, {User: a}, {User: b}, {User: c}}}
+	var got []string
+	for _, u := range i.Participants() {
+		got = append(got, u.ID)
+	}
+	if len(got) != 3 || got[0] != "alice" || got[1] != "bob" || got[2] != "carol" {
+		t.Errorf("got %v, want [alice bob carol]", got)
+	}
+}
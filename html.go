@@ -0,0 +1,281 @@
+package main
+
+import (
+	"html/template"
+	"io"
+	"sort"
+)
+
+// htmlTemplates render the reports as self-contained HTML pages
+var htmlTemplates = template.Must(template.New("html").Funcs(template.FuncMap(templateFuncs)).Parse(htmlSource))
+
+// byRepo groups Items by repository. Groups are sorted by repository
+// and the Items in a group by number.
+func byRepo(items Items) []*ItemGroup {
+	var groups []*ItemGroup
+	groupOf := make(map[string]*ItemGroup)
+	for _, i := range items {
+		g, ok := groupOf[i.Repo]
+		if !ok {
+			g = &ItemGroup{Label: i.Repo}
+			groupOf[i.Repo] = g
+			groups = append(groups, g)
+		}
+		g.Items = append(g.Items, i)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Label < groups[j].Label })
+	for _, g := range groups {
+		sort.Slice(g.Items, func(i, j int) bool { return g.Items[i].Number < g.Items[j].Number })
+	}
+	return groups
+}
+
+// HTML writes the repository report as an HTML page to w
+func (r *RepoReport) HTML(w io.Writer) error {
+	return htmlTemplates.ExecuteTemplate(w, "repo-report", r)
+}
+
+// HTML writes the user report as an HTML page to w
+func (r *UserReport) HTML(w io.Writer) error {
+	return htmlTemplates.ExecuteTemplate(w, "user-report", r)
+}
+
+// HTML writes the team report as an HTML page to w
+func (r *TeamReport) HTML(w io.Writer) error {
+	return htmlTemplates.ExecuteTemplate(w, "team-report", r)
+}
+
+// HTML writes the changelog as an HTML page to w
+func (cl *Changelog) HTML(w io.Writer) error {
+	return htmlTemplates.ExecuteTemplate(w, "changelog", cl)
+}
+
+const htmlSource = `
+{{define "header"}}<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; font-size: 14px; line-height: 1.5; color: #24292e; max-width: 960px; margin: 2em auto; padding: 0 1em; }
+h1 { border-bottom: 1px solid #eaecef; padding-bottom: .3em; }
+a { color: #0366d6; text-decoration: none; }
+a:hover { text-decoration: underline; }
+details { margin: 1em 0; border: 1px solid #e1e4e8; border-radius: 6px; padding: .5em 1em; }
+summary { font-size: 1.3em; font-weight: 600; cursor: pointer; }
+h3 { margin: 1em 0 .3em; font-size: 1.1em; }
+ul { margin: 0; padding-left: 1.5em; }
+li { margin: .2em 0; }
+.id { color: #586069; }
+.user { white-space: nowrap; margin-left: .3em; }
+.avatar { width: 20px; height: 20px; border-radius: 3px; vertical-align: middle; margin-right: .2em; }
+table { border-collapse: collapse; margin: 1em 0; }
+th, td { border: 1px solid #dfe2e5; padding: .3em .8em; text-align: left; }
+th { background: #f6f8fa; }
+</style>
+</head>
+<body>
+{{end}}
+
+{{define "footer"}}</body>
+</html>
+{{end}}
+
+{{define "user"}}<a class="user" href="{{.URL}}">{{if .AvatarURL}}<img class="avatar" src="{{.AvatarURL}}" alt="">{{end}}@{{.ID}}</a>{{end}}
+
+{{define "item"}}<li><a href="{{.URL}}">{{.Title}}</a> <span class="id">{{.ID}}</span>{{range .Participants}}{{template "user" .}}{{end}}</li>
+{{end}}
+
+{{define "items"}}{{range byRepo .}}<h3>{{.Label}}</h3>
+<ul>
+{{range .Items}}{{template "item" .}}{{end}}</ul>
+{{else}}<p>None.</p>
+{{end}}{{end}}
+
+{{define "groups"}}{{range .}}<h3>{{if .Label}}{{.Label}}{{else}}Other{{end}}</h3>
+<ul>
+{{range .Items}}{{template "item" .}}{{end}}</ul>
+{{end}}{{end}}
+
+{{define "trend-row"}}<tr><td>{{.Period}}</td><td>{{.Summary.Contributions}}</td><td>{{.Summary.Contributors}}</td><td>{{.Summary.OpenedPRs}}</td><td>{{.Summary.MergedPRs}}</td><td>{{.Summary.OpenedIssues}}</td><td>{{.Summary.ClosedIssues}}</td></tr>
+{{end}}
+
+{{define "user-summary-header"}}<tr><th>User</th><th>PRs opened</th><th>PRs merged</th><th>Reviews given</th><th>Review comments</th><th>Issues opened</th><th>Issues commented</th></tr>
+{{end}}
+
+{{define "user-summary-row"}}<tr><td>@{{.User}}</td><td>{{.Summary.OpenedPRs}}</td><td>{{.Summary.MergedPRs}}</td><td>{{.Summary.Reviews}}</td><td>{{.Summary.ReviewComments}}</td><td>{{.Summary.OpenedIssues}}</td><td>{{.Summary.CommentedIssues}}</td></tr>
+{{end}}
+
+{{define "repo-report"}}{{template "header" printf "Report for %s" .Period}}
+<h1>Report for {{.Period}}</h1>
+<p>This report covers the development in the
+{{range .Repos}}<a href="{{$.BaseURL}}{{.}}">{{.}}</a> {{end}}repositories.
+{{$p := .Previous}}There were {{.Summary.Contributions}}{{if $p}} ({{delta .Summary.Contributions $p.Contributions}}){{end}} contributions (PRs/Issues/Comments) from {{.Summary.Contributors}}{{if $p}} ({{delta .Summary.Contributors $p.Contributors}}){{end}} individual contributors.
+{{.Summary.OpenedPRs}}{{if $p}} ({{delta .Summary.OpenedPRs $p.OpenedPRs}}){{end}} new PRs were opened and {{.Summary.MergedPRs}}{{if $p}} ({{delta .Summary.MergedPRs $p.MergedPRs}}){{end}} PRs were merged.
+{{.Summary.OpenedIssues}}{{if $p}} ({{delta .Summary.OpenedIssues $p.OpenedIssues}}){{end}} new issues were opened and {{.Summary.ClosedIssues}}{{if $p}} ({{delta .Summary.ClosedIssues $p.ClosedIssues}}){{end}} issues were closed.{{with .Commits}}
+{{.Count}} commits from {{.Authors}} distinct authors landed on the default branches.{{end}}{{if $p}}
+Changes are relative to {{(index .Trend 0).Period}}.{{end}}</p>
+{{if .Trend}}
+<details open>
+<summary>Trend</summary>
+<table>
+<tr><th>Period</th><th>Contributions</th><th>Contributors</th><th>New PRs</th><th>Merged PRs</th><th>New issues</th><th>Closed issues</th></tr>
+{{template "trend-row" .}}{{range .Trend}}{{template "trend-row" .}}{{end}}</table>
+</details>
+{{end}}
+<details>
+<summary>Metrics</summary>
+<p>Median / 90th percentile (number of PRs/Issues)</p>
+<table>
+<tr><th>Repository</th><th>First response</th><th>Time to merge</th><th>Time to close</th><th>No response yet</th></tr>
+{{range .Metrics}}<tr><td>{{if .Repo}}{{.Repo}}{{else}}All{{end}}</td><td>{{.FirstResponse}}</td><td>{{.TimeToMerge}}</td><td>{{.TimeToClose}}</td><td>{{.NoResponse}}</td></tr>
+{{end}}</table>
+</details>
+{{with .Reviews}}{{if or .Reviewers .Merged}}
+<details>
+<summary>Reviews</summary>
+<p>Merged PRs went through {{printf "%.1f" .AvgRounds}} review rounds on average.</p>
+<table>
+<tr><th>Reviewer</th><th>Approvals</th><th>Changes requested</th><th>Comments</th><th>Line comments</th></tr>
+{{range .Reviewers}}<tr><td>{{template "user" .User}}</td><td>{{.Approvals}}</td><td>{{.ChangesRequested}}</td><td>{{.Comments}}</td><td>{{.LineComments}}</td></tr>
+{{end}}</table>
+{{if .Unapproved}}<h3>Merged without approval ({{len .Unapproved}})</h3>
+{{template "items" .Unapproved}}{{end}}</details>
+{{end}}{{end}}{{with .Sizes}}{{if .Merged}}
+<details>
+<summary>PR sizes</summary>
+<p>The merged PRs added {{.Additions}} and removed {{.Deletions}} lines.</p>
+<table>
+<tr><th>Size</th><th>Lines changed</th><th>PRs</th></tr>
+{{range .Buckets}}<tr><td>{{.Label}}</td><td>{{.Lines}}</td><td>{{.PRs}}</td></tr>
+{{end}}</table>
+<h3>Largest merged PRs</h3>
+<ul>
+{{range .Largest}}<li><a href="{{.URL}}">{{.Title}}</a> <span class="id">{{.ID}}</span>{{template "user" .CreatedBy}} +{{.Additions}} -{{.Deletions}} in {{.ChangedFiles}} files</li>
+{{end}}</ul>
+</details>
+{{end}}{{end}}{{if .LabelCounts}}
+<details>
+<summary>Labels</summary>
+<table>
+<tr><th>Label</th><th>Opened</th><th>Closed</th></tr>
+{{range .LabelCounts}}<tr><td>{{.Label}}</td><td>{{.Opened}}</td><td>{{.Closed}}</td></tr>
+{{end}}</table>
+</details>
+{{end}}
+<details open>
+<summary>Merged PRs ({{len .MergedPRs}})</summary>
+{{if .GroupLabel}}{{template "groups" .MergedPRGroups}}{{else}}{{template "items" .MergedPRs}}{{end}}</details>
+
+<details open>
+<summary>Closed Issues ({{len .ClosedIssues}})</summary>
+{{if .GroupLabel}}{{template "groups" .ClosedIssueGroups}}{{else}}{{template "items" .ClosedIssues}}{{end}}</details>
+
+<details>
+<summary>New or updated PRs and Issues, not closed ({{len .UpdatedItems}})</summary>
+{{template "items" .UpdatedItems}}</details>
+{{with .Commits}}{{if .Direct}}
+<details>
+<summary>Commits without a PR ({{len .Direct}})</summary>
+<ul>
+{{range .Direct}}<li><a href="{{.URL}}">{{.Title}}</a> <span class="id">{{.ID}}</span>{{if .Author}}{{template "user" .Author}}{{else}} <span class="user">{{.AuthorName}}</span>{{end}}</li>
+{{end}}</ul>
+</details>
+{{end}}{{end}}{{if .Newcomers}}
+<details open>
+<summary>Welcome ({{len .Newcomers}})</summary>
+<p>A warm welcome to our new contributors:</p>
+<ul>
+{{range .Newcomers}}<li>{{template "user" .User}} {{if eq .Kind "comment"}}commented on{{else}}opened the {{.Kind}}{{end}} <a href="{{.First.URL}}">{{.First.Title}}</a> <span class="id">{{.First.ID}}</span> on {{date .At}}</li>
+{{end}}</ul>
+</details>
+{{end}}{{if .NewcomersUnchecked}}
+<p>Earlier activity could not be checked for {{range $i, $u := .NewcomersUnchecked}}{{if $i}}, {{end}}{{template "user" $u}}{{end}}, who may be new as well.</p>
+{{end}}{{with .Backlog}}
+<details>
+<summary>Backlog</summary>
+<p>At the start of the period {{.OpenPRsAtStart}} PRs and {{.OpenIssuesAtStart}} issues were open, at the end {{.OpenPRsAtEnd}} PRs and {{.OpenIssuesAtEnd}} issues.</p>
+<table>
+<tr><th>Age</th><th>PRs</th><th>Issues</th></tr>
+{{range .Ages}}<tr><td>{{.Label}}</td><td>{{.PRs}}</td><td>{{.Issues}}</td></tr>
+{{end}}</table>
+<h3>No activity for more than {{.StaleDays}} days ({{len .Stale}})</h3>
+{{template "items" .Stale}}
+<h3>Oldest unanswered</h3>
+<ul>
+{{range .Unanswered}}<li><a href="{{.URL}}">{{.Title}}</a> <span class="id">{{.ID}}</span>{{template "user" .CreatedBy}} opened {{date .CreatedAt}}</li>
+{{end}}</ul>
+</details>
+{{end}}{{if .BotActivity}}
+<details>
+<summary>Bot activity</summary>
+<table>
+<tr><th>User</th><th>PRs/Issues opened</th><th>PRs merged</th><th>Comments</th></tr>
+{{range .BotActivity}}<tr><td>{{template "user" .User}}</td><td>{{.Opened}}</td><td>{{.Merged}}</td><td>{{.Comments}}</td></tr>
+{{end}}</table>
+</details>
+{{end}}
+{{template "footer"}}{{end}}
+
+{{define "user-report"}}{{template "header" printf "Report for %s" .User}}
+<h1>Report for @{{.User}} from {{.Period}}</h1>
+<table>
+{{template "user-summary-header"}}{{template "user-summary-row" .}}</table>
+
+<details open>
+<summary>PRs ({{len .PRs}})</summary>
+{{template "items" .PRs}}</details>
+
+<details open>
+<summary>Reviewed PRs ({{len .ReviewedPRs}})</summary>
+{{template "items" .ReviewedPRs}}</details>
+
+<details open>
+<summary>Issues ({{len .Issues}})</summary>
+{{template "items" .Issues}}</details>
+
+<details open>
+<summary>Issues commented on ({{len .CommentedIssues}})</summary>
+{{template "items" .CommentedIssues}}</details>
+{{template "footer"}}{{end}}
+
+{{define "team-report"}}{{if .Team}}{{template "header" printf "Report for team %s" .Team}}{{else}}{{template "header" "Report"}}{{end}}
+<h1>Report for {{if .Team}}team {{.Team}}{{else}}{{range $n, $m := .Members}}{{if $n}}, {{end}}@{{.User}}{{end}}{{end}} from {{.Period}}</h1>
+<p>{{if .Team}}The {{.Totals.Members}} members of the team{{else}}The {{.Totals.Members}} users{{end}} opened {{.Totals.PRs}} PRs and {{.Totals.Issues}} issues, reviewed {{.Totals.ReviewedPRs}} PRs and commented on {{.Totals.CommentedIssues}} issues.</p>
+<table>
+{{template "user-summary-header"}}{{range .Members}}{{template "user-summary-row" .}}{{end}}</table>
+{{range .Members}}
+<details open>
+<summary>@{{.User}}</summary>
+<h3>PRs ({{len .PRs}})</h3>
+{{template "items" .PRs}}
+<h3>Reviewed PRs ({{len .ReviewedPRs}})</h3>
+{{template "items" .ReviewedPRs}}
+<h3>Issues ({{len .Issues}})</h3>
+{{template "items" .Issues}}
+<h3>Issues commented on ({{len .CommentedIssues}})</h3>
+{{template "items" .CommentedIssues}}</details>
+{{end}}
+{{template "footer"}}{{end}}
+
+{{define "changelog"}}{{template "header" printf "Changes in %s" .Repo}}
+<h1>Changes in <a href="{{.BaseURL}}{{.Repo}}">{{.Repo}}</a> from {{.From}} to {{.To}}</h1>
+
+{{range .Categories}}<details open>
+<summary>{{.Label}} ({{len .Items}})</summary>
+<ul>
+{{range .Items}}<li><a href="{{.URL}}">{{.Title}}</a> <span class="id">{{.ID}}</span>{{template "user" .CreatedBy}}</li>
+{{end}}</ul>
+</details>
+{{end}}
+{{if .Contributors}}<h2>Contributors</h2>
+<p>Thanks to{{range .Contributors}}{{template "user" .}}{{end}} for their contributions.</p>
+{{end}}
+{{if .FirstTimers}}<p>A special welcome to our first-time contributors:</p>
+<ul>
+{{range .FirstTimers}}<li>{{template "user" .User}} with <a href="{{.Item.URL}}">{{.Item.ID}}</a></li>
+{{end}}</ul>
+{{end}}{{if .FirstTimersUnchecked}}<p>Earlier PRs could not be checked for {{range $i, $u := .FirstTimersUnchecked}}{{if $i}}, {{end}}{{template "user" $u}}{{end}}, who may be first-time contributors as well.</p>
+{{end}}
+{{template "footer"}}{{end}}
+`
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// LabelCount holds the number of PRs and Issues with a label which
+// were opened and closed in a period
+type LabelCount struct {
+	Label  string
+	Opened int
+	Closed int
+}
+
+// NewLabelCounts counts the PRs and Issues opened and closed in the
+// period for every label. The result is sorted by label.
+func NewLabelCounts(period *Period, items Items) []*LabelCount {
+	counts := make(map[string]*LabelCount)
+	for _, i := range items {
+		opened := period.Match(i.CreatedAt)
+		closed := period.Match(i.ClosedAt)
+		if !opened && !closed {
+			continue
+		}
+		for _, l := range i.Labels {
+			lc, ok := counts[l]
+			if !ok {
+				lc = &LabelCount{Label: l}
+				counts[l] = lc
+			}
+			if opened {
+				lc.Opened++
+			}
+			if closed {
+				lc.Closed++
+			}
+		}
+	}
+
+	ret := make([]*LabelCount, 0, len(counts))
+	for _, lc := range counts {
+		ret = append(ret, lc)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Label < ret[j].Label })
+	return ret
+}
+
+// ItemGroup is a list of Items sharing a label
+type ItemGroup struct {
+	// Label is empty for the group of Items without a matching label
+	Label string
+	Items Items
+}
+
+// GroupByLabel groups Items by their labels starting with prefix. An
+// Item with several matching labels is in several groups. Groups are
+// sorted by label with Items without a matching label last.
+func (items Items) GroupByLabel(prefix string) []*ItemGroup {
+	groups := make(map[string]*ItemGroup)
+	add := func(label string, i *Item) {
+		g, ok := groups[label]
+		if !ok {
+			g = &ItemGroup{Label: label}
+			groups[label] = g
+		}
+		g.Items = append(g.Items, i)
+	}
+	for _, i := range items {
+		var matched bool
+		for _, l := range i.Labels {
+			if strings.HasPrefix(l, prefix) {
+				add(l, i)
+				matched = true
+			}
+		}
+		if !matched {
+			add("", i)
+		}
+	}
+
+	ret := make([]*ItemGroup, 0, len(groups))
+	for _, g := range groups {
+		ret = append(ret, g)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Label == "" || ret[j].Label == "" {
+			return ret[j].Label == ""
+		}
+		return ret[i].Label < ret[j].Label
+	})
+	return ret
+}
@@ -5,7 +5,13 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
+	"text/template"
+	"time"
 
 	"github.com/google/go-github/github"
 	"golang.org/x/oauth2"
@@ -13,234 +19,411 @@ import (
 
 func main() {
 	accessToken := flag.String("token", "", "GitHub access token")
+	baseURL := flag.String("base-url", "", "GitHub Enterprise API URL, e.g. https://github.example.com/api/v3/")
 	monthly := flag.String("monthly", "", "Month to generate the report for, e.g. 2018-01")
 	weekly := flag.String("weekly", "", "(ISO) week to generate the report for, e.g. 2018-01")
-	user := flag.String("user", "", "Only report activity for a single user")
+	quarterly := flag.String("quarterly", "", "Quarter to generate the report for, e.g. 2018-Q2")
+	yearly := flag.String("yearly", "", "Year to generate the report for, e.g. 2018")
+	from := flag.String("from", "", "First day to generate the report for, e.g. 2018-03-05 (requires -to)")
+	to := flag.String("to", "", "Last day to generate the report for, e.g. 2018-03-19 (requires -from)")
+	last := flag.String("last", "", "Generate the report for the last days or weeks, e.g. 14d or 2w")
+	release := flag.String("release", "", "Generate a changelog for a release (by tag or name) since the previous release")
+	fromTag := flag.String("from-tag", "", "Generate a changelog starting from this tag (requires -to-tag)")
+	toTag := flag.String("to-tag", "", "Generate a changelog up to this tag (requires -from-tag)")
+	var users stringList
+	flag.Var(&users, "user", "Only report activity for these users (may be repeated)")
+	team := flag.String("team", "", "Report activity of the members of a team, e.g. linuxkit/maintainers")
+	var orgs, include, exclude stringList
+	flag.Var(&orgs, "org", "Report on all repositories of an organisation (may be repeated)")
+	flag.Var(&include, "include", "Only include organisation repositories matching these glob patterns")
+	flag.Var(&exclude, "exclude", "Exclude organisation repositories matching these glob patterns")
+	archived := flag.Bool("archived", false, "Include archived organisation repositories")
+	forks := flag.Bool("forks", false, "Include forked organisation repositories")
+	compare := flag.Int("compare", 0, "Compare the counters with this many preceding periods")
+	backlog := flag.Bool("backlog", false, "Add a section about the backlog of open PRs and Issues")
+	staleDays := flag.Int("stale-days", 30, "Days without activity after which an open PR or Issue is stale")
+	commits := flag.Bool("commits", false, "Add a section about commits on the default branches")
+	newcomers := flag.Bool("newcomers", false, "Add a section welcoming first-time contributors")
+	groupBy := flag.String("group-by-label", "", "Group merged PRs and closed Issues by labels with this prefix, e.g. kind/")
+	bots := flag.String("bots", BotsInclude, "How to handle bots and ignored users: include, exclude or separate")
+	var ignoreUsers stringList
+	flag.Var(&ignoreUsers, "ignore-users", "Users to handle like bots, e.g. CI or release accounts")
+	format := flag.String("format", FormatMarkdown, "Output format, either markdown, json or html")
+	templateFile := flag.String("template", "", "Render the report with this Go text/template file instead")
+	cacheDir := flag.String("cache", DefaultCacheDir(), "Directory to cache fetched PRs and Issues in")
+	noCache := flag.Bool("no-cache", false, "Do not use the cache")
+	clearCache := flag.Bool("clear-cache", false, "Clear the cache before fetching")
+	record := flag.String("record", "", "Record all GitHub API responses in this directory")
+	replay := flag.String("replay", "", "Replay the GitHub API responses recorded in this directory instead of using GitHub")
+	stateFile := flag.String("state", "", "Keep PRs and Issues in this file and only fetch the ones updated since the last run")
+	offline := flag.Bool("offline", false, "Report from the -state file only, without using GitHub")
+	exportDB := flag.String("export-sqlite", "", "Export the PRs and Issues of the period to this SQLite database instead of writing a report (- writes SQL to stdout)")
+	concurrency := flag.Int("concurrency", 4, "Number of repositories and PRs/Issues to fetch in parallel")
 	verbose := flag.Int("v", 0, "Verbosity level")
 	flag.Parse()
 
-	if *accessToken == "" {
+	if *record != "" && *replay != "" {
+		log.Fatal("Please specify either -record or -replay")
+	}
+	if *offline && (*stateFile == "" || *record != "" || *replay != "") {
+		log.Fatal("Please specify -offline with -state and without -record or -replay")
+	}
+	if *accessToken == "" && *replay == "" && !*offline {
 		log.Fatal("Please specify a access token")
 	}
 	logLevel = *verbose
 
-	if (*monthly == "" && *weekly == "") || (*monthly != "" && *weekly != "") {
-		log.Fatal("Please specify either a month or a week")
+	if *format != FormatMarkdown && *format != FormatJSON && *format != FormatHTML {
+		log.Fatalf("Unknown output format: %s", *format)
 	}
-	var err error
-	var period *Period
-	if *monthly != "" {
-		period, err = NewPeriodFromMonth(*monthly)
+	var tmpl *template.Template
+	if *templateFile != "" {
+		var err error
+		tmpl, err = LoadTemplate(*templateFile)
 		if err != nil {
-			log.Fatal("Error parsing month:", err)
+			log.Fatal("Error loading template: ", err)
+		}
+	}
+	if *bots != BotsInclude && *bots != BotsExclude && *bots != BotsSeparate {
+		log.Fatalf("Unknown way to handle bots: %s", *bots)
+	}
+	// Ignored users are excluded unless -bots says otherwise, but bots
+	// are only handled differently if -bots is given
+	filterBots := true
+	if len(ignoreUsers) > 0 {
+		botsSet := false
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name == "bots" {
+				botsSet = true
+			}
+		})
+		if !botsSet {
+			*bots = BotsExclude
+			filterBots = false
 		}
 	}
-	if *weekly != "" {
-		period, err = NewPeriodFromWeek(*weekly)
+
+	if len(users) > 0 && *team != "" {
+		log.Fatal("Please specify either a user or a team")
+	}
+
+	changelog := *release != "" || *fromTag != "" || *toTag != ""
+	if *offline && (changelog || len(orgs) > 0 || *team != "" || *backlog || *newcomers || *commits) {
+		log.Fatal("Changelogs, -org, -team, -backlog, -newcomers and -commits need GitHub and can not be used with -offline")
+	}
+	if changelog && *release != "" && (*fromTag != "" || *toTag != "") {
+		log.Fatal("Please specify either a release or tags")
+	}
+	if changelog && *release == "" && (*fromTag == "" || *toTag == "") {
+		log.Fatal("Please specify both -from-tag and -to-tag")
+	}
+	if changelog && *exportDB != "" {
+		log.Fatal("Please specify either a changelog or -export-sqlite")
+	}
+
+	var err error
+	var period *Period
+	if !changelog {
+		period, err = parsePeriod(*monthly, *weekly, *quarterly, *yearly, *from, *to, *last)
 		if err != nil {
-			log.Fatal("Error parsing week:", err)
+			log.Fatal(err)
 		}
+		infof("FROM %s TO %s\n", period.Start, period.End)
 	}
-	infof("FROM %s TO %s\n", period.Start, period.End)
 
 	repos := flag.Args()
 
 	ctx := context.Background()
-	ts := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: *accessToken},
-	)
-	tc := oauth2.NewClient(ctx, ts)
+	var tc *http.Client
+	if *replay != "" {
+		tc = &http.Client{Transport: &Replayer{Dir: *replay}}
+	} else if *offline {
+		tc = &http.Client{}
+	} else {
+		ts := oauth2.StaticTokenSource(
+			&oauth2.Token{AccessToken: *accessToken},
+		)
+		tc = oauth2.NewClient(ctx, ts)
+	}
+	if *record != "" {
+		recorder, err := NewRecorder(*record, tc.Transport)
+		if err != nil {
+			log.Fatal("Error creating recorder: ", err)
+		}
+		tc.Transport = recorder
+	}
 
-	client := github.NewClient(tc)
+	client, err := newClient(tc, *baseURL)
+	if err != nil {
+		log.Fatal("Error creating client: ", err)
+	}
 
-	// Gather information about PRs/Issues/Users
+	// Cached Items would hide requests from the recording
+	var cache *Cache
+	if !*noCache && *record == "" && *replay == "" && !*offline {
+		// Keep entries from different GitHub instances apart
+		cache, err = NewCache(filepath.Join(*cacheDir, client.BaseURL.Host))
+		if err != nil {
+			log.Fatal("Error creating cache:", err)
+		}
+		if *clearCache {
+			if err := cache.Clear(); err != nil {
+				log.Fatal("Error clearing cache:", err)
+			}
+		}
+	}
 
-	allUsers := make(Users)
-	var allPRs Items
-	var allIssues Items
+	fetcher := NewFetcher(client, cache, *concurrency)
 
-	for _, ownerAndRepo := range repos {
-		t := strings.SplitN(ownerAndRepo, "/", 2)
-		if len(t) != 2 {
-			log.Printf("%s is malformed.", ownerAndRepo)
+	if len(orgs) > 0 {
+		filter := &RepoFilter{
+			Include:  include,
+			Exclude:  exclude,
+			Archived: *archived,
+			Forks:    *forks,
+		}
+		if err := filter.Validate(); err != nil {
+			log.Fatal("Error in repository filter: ", err)
 		}
-		owner := t[0]
-		repo := t[1]
+		for _, org := range orgs {
+			orgRepos, err := fetcher.GetOrgRepos(ctx, org, filter)
+			if err != nil {
+				log.Fatalf("Error getting repositories for %s: %v", org, err)
+			}
+			infof("Repositories of %s: %v\n", org, orgRepos)
+			repos = appendUnique(repos, orgRepos...)
+		}
+	}
+	if len(repos) == 0 {
+		log.Fatal("Please specify some repositories or an organisation")
+	}
 
-		// Handle PRs
-		infof("Get PRs:\n")
-		if err := GetPRs(ctx, client, owner, repo, &period.Start, &allPRs, &allUsers); err != nil {
-			log.Printf("Error getting PRs for %s: %v", repo, err)
+	if changelog {
+		if len(repos) != 1 {
+			log.Fatal("Please specify exactly one repository for a changelog")
+		}
+		t := strings.SplitN(repos[0], "/", 2)
+		if len(t) != 2 {
+			log.Fatalf("%s is malformed.", repos[0])
 		}
+		from, to := *fromTag, *toTag
+		if *release != "" {
+			from, to, err = fetcher.GetReleaseRange(ctx, t[0], t[1], *release)
+			if err != nil {
+				log.Fatal("Error getting releases: ", err)
+			}
+		}
+		cl, err := fetcher.NewChangelog(ctx, WebURL(client), t[0], t[1], from, to)
+		if err != nil {
+			log.Fatal("Error creating changelog: ", err)
+		}
+		if err := output(cl, *format, tmpl); err != nil {
+			log.Fatal("Error rendering changelog:", err)
+		}
+		warnIncomplete(fetcher)
+		return
+	}
 
-		// Handle issues
-		infof("Get Issues:\n")
-		if err := GetIssues(ctx, client, owner, repo, &period.Start, &allIssues, &allUsers); err != nil {
-			log.Printf("Error getting Issues for %s: %v", repo, err)
+	var members []string
+	if *team != "" {
+		members, err = fetcher.GetTeamMembers(ctx, *team)
+		if err != nil {
+			log.Fatalf("Error getting members of %s: %v", *team, err)
 		}
+		infof("Members of %s: %v\n", *team, members)
 	}
 
-	if *user != "" {
-		userReport(repos, period, *user, allPRs, allIssues)
-	} else {
-		repoReport(repos, period, allPRs, allIssues)
+	// Gather information about PRs/Issues/Users
+
+	// Fetch everything needed for the preceding periods as well
+	previous := PreviousPeriods(period, *compare)
+	since := period.Start
+	if len(previous) > 0 {
+		since = previous[len(previous)-1].Start
 	}
-}
 
-// repoReport generates a report about activity on Repositories
-func repoReport(repos []string, period *Period, allPRs, allIssues Items) {
-	var mergedPRs Items
-	var closedIssues Items
-	var updatedItems Items
-
-	var openedPRsCount int
-	var openedIssuesCount int
-	var contributions int
-	contributors := make(Users)
-	users := make(Users)
-
-	for _, i := range append(allPRs, allIssues...) {
-		infof("Processing: %s\n", i)
-		debugf("%s\n\n", i.Dump())
-
-		var updated bool
-		
-		// Record all users as they may be linked in Issues/PRs
-		users[i.CreatedBy.ID] = i.CreatedBy
-
-		// Handle comments first
-		for _, comment := range i.Comments {
-			if period.Match(comment.CreatedAt) {
-				contributions++
-				contributors[comment.User.ID] = comment.User
-				updated = true
-			}
-			// Record all users as they may be linked in Issues/PRs
-			users[comment.User.ID] = comment.User
-		}
-
-		// Next handle contributions from new Items
-		if period.Match(i.CreatedAt) {
-			updated = true
-			// Only count contributor here. Item will be put on the appropriate list below
-			contributions++
-			contributors[i.CreatedBy.ID] = i.CreatedBy
-			if i.PR {
-				openedPRsCount++
-			} else {
-				openedIssuesCount++
-			}
+	allUsers := make(Users)
+	var allPRs, allIssues Items
+	if *stateFile != "" {
+		state, err := LoadState(*stateFile)
+		if err != nil {
+			log.Fatal("Error loading state: ", err)
 		}
-
-		// Next handle closed PRs and issues
-		if period.Match(i.ClosedAt) {
-			if i.PR {
-				if i.Merged {
-					mergedPRs = append(mergedPRs, i)
-					// Sigh...sometimes MergedBy is not filled in
-					if i.MergedBy != nil {
-						users[i.MergedBy.ID] = i.MergedBy
-						contributors[i.MergedBy.ID] = i.MergedBy
-					}
-				} else {
-					// PR was *not* merged. Count as updated
-					updatedItems = append(updatedItems, i)
+		if *offline {
+			for _, repo := range repos {
+				if !state.Covers(repo, since) {
+					log.Fatalf("The state does not cover %s since %s", repo, since.Format("2006-01-02"))
 				}
-			} else {
-				// Issues, just add to closed issues list
-				closedIssues = append(closedIssues, i)
 			}
 		} else {
-			if updated {
-				// Not closed, but updated, so add to updated list
-				// Contributions were already counted.
-				updatedItems = append(updatedItems, i)
+			fetcher.Sync(ctx, state, repos, since, &allUsers)
+			if err := state.Save(); err != nil {
+				log.Fatal("Error saving state: ", err)
 			}
 		}
+		allPRs, allIssues = state.Items(repos, since, &allUsers)
+	} else {
+		allPRs, allIssues = fetcher.GetItems(ctx, repos, "all", &since, &allUsers)
 	}
 
-	// Print output in markdown fragments
+	if *exportDB != "" {
+		if err := ExportSQLite(*exportDB, append(allPRs, allIssues...), allUsers, fetcher.Incomplete()); err != nil {
+			log.Fatal("Error exporting to SQLite: ", err)
+		}
+		warnIncomplete(fetcher)
+		return
+	}
 
-	fmt.Printf("# Report for %s\n", period)
-	fmt.Println()
-	fmt.Printf("This report covers the development in the")
-	for _, ownerAndRepo := range repos {
-		fmt.Printf(" [%s]", ownerAndRepo)
+	var openPRs, openIssues Items
+	if *backlog {
+		openPRs, openIssues = fetcher.GetItems(ctx, repos, "open", nil, &allUsers)
 	}
-	fmt.Printf(" repositories. There were %d contributions (PRs/Issues/Comments) from %d individual contributors. %d new PRs were opened and %d PRs were merged. %d new issues were opened and %d issues were closed.\n", contributions, len(contributors), openedPRsCount, len(mergedPRs), openedIssuesCount, len(closedIssues))
-	fmt.Println()
 
-	// Details
-	fmt.Println("## Merged PRs:")
-	fmt.Println(mergedPRs)
-	fmt.Println()
-	fmt.Println("## Closed Issues:")
-	fmt.Println(closedIssues)
-	fmt.Println()
-	fmt.Println("## New or updated PRs and Issues (not closed):")
-	fmt.Println(updatedItems)
+	// Check commits against all PRs, including the ones of bots
+	var commitActivity *CommitActivity
+	if *commits {
+		commitActivity = NewCommitActivity(fetcher.GetCommits(ctx, repos, period, &allUsers), allPRs)
+	}
 
-	// Links
-	fmt.Println()
-	for _, ownerAndRepo := range repos {
-		fmt.Printf("[%s]: https://github.com/%s\n", ownerAndRepo, ownerAndRepo)
+	// Handle bots and ignored users
+	userFilter := NewUserFilter(ignoreUsers, filterBots)
+	var botActivity []*BotActivity
+	if *bots == BotsSeparate {
+		botActivity = userFilter.NewBotActivity(period, append(allPRs, allIssues...))
+	}
+	if *bots != BotsInclude {
+		allPRs = userFilter.Filter(allPRs)
+		allIssues = userFilter.Filter(allIssues)
+		openPRs = userFilter.Filter(openPRs)
+		openIssues = userFilter.Filter(openIssues)
 	}
-	fmt.Println(mergedPRs.Links())
-	fmt.Println(closedIssues.Links())
-	fmt.Println(updatedItems.Links())
-	fmt.Println(users.Links())
-}
 
-// userReport generates a report about activity of a single user
-func userReport(repos []string, period *Period, user string, allPRs, allIssues Items) {
-	var userPRs Items
-	var reviewedPRs Items
-	var userIssues Items
-	var commentIssues Items
-
-	for _, pr := range allPRs {
-		if period.Match(pr.CreatedAt) && pr.CreatedBy.ID == user {
-			userPRs = append(userPRs, pr)
-			continue
-		}
-		if period.Match(pr.ClosedAt) && pr.MergedBy != nil && pr.MergedBy.ID == user {
-			reviewedPRs = append(reviewedPRs, pr)
-			continue
-		}
-		for _, comment := range pr.Comments {
-			if period.Match(comment.CreatedAt) && comment.User.ID == user {
-				reviewedPRs = append(reviewedPRs, pr)
-				break
-			}
+	webURL := WebURL(client)
+	var report Report
+	if len(users) == 1 {
+		report = NewUserReport(webURL, repos, period, users[0], allPRs, allIssues)
+	} else if len(users) > 1 {
+		report = NewTeamReport(webURL, repos, period, "", users, allPRs, allIssues)
+	} else if *team != "" {
+		report = NewTeamReport(webURL, repos, period, *team, members, allPRs, allIssues)
+	} else {
+		r := NewRepoReport(webURL, repos, period, allPRs, allIssues)
+		if *groupBy != "" {
+			r.GroupByLabel(*groupBy)
+		}
+		r.Compare(previous, allPRs, allIssues)
+		if *newcomers {
+			r.Newcomers, r.NewcomersUnchecked = fetcher.GetNewcomers(ctx, repos, period, append(allPRs, allIssues...))
+		}
+		if *backlog {
+			r.Backlog = NewBacklog(period, *staleDays, append(openPRs, openIssues...), append(allPRs, allIssues...))
 		}
+		r.Commits = commitActivity
+		r.BotActivity = botActivity
+		report = r
+	}
+	if err := output(report, *format, tmpl); err != nil {
+		log.Fatal("Error rendering report:", err)
+	}
+	warnIncomplete(fetcher)
+}
+
+// warnIncomplete lists the Items and repositories which could not be
+// fully fetched and may be missing or incomplete in the report
+func warnIncomplete(f *Fetcher) {
+	incomplete := f.Incomplete()
+	if len(incomplete) == 0 {
+		return
+	}
+	warnf("The report may be incomplete. Could not fully fetch:\n")
+	for _, fe := range incomplete {
+		warnf("  %s: %v\n", fe.ID, fe.Err)
 	}
-	for _, i := range allIssues {
-		if period.Match(i.CreatedAt) && i.CreatedBy.ID == user {
-			userIssues = append(userIssues, i)
-			continue
+}
+
+// output writes the report to stdout, either with the user supplied
+// template or in the given format
+func output(r Report, format string, tmpl *template.Template) error {
+	if tmpl != nil {
+		return tmpl.Execute(os.Stdout, r)
+	}
+	return Render(os.Stdout, r, format)
+}
+
+// parsePeriod creates the period from the command line options.
+// Exactly one of them must be specified, with from and to counting as one.
+func parsePeriod(monthly, weekly, quarterly, yearly, from, to, last string) (*Period, error) {
+	var periods int
+	for _, p := range []string{monthly, weekly, quarterly, yearly, last} {
+		if p != "" {
+			periods++
 		}
-		for _, comment := range i.Comments {
-			if period.Match(comment.CreatedAt) && comment.User.ID == user {
-				commentIssues = append(commentIssues, i)
-				break
-			}
+	}
+	if from != "" || to != "" {
+		if from == "" || to == "" {
+			return nil, fmt.Errorf("Please specify both -from and -to")
 		}
+		periods++
+	}
+	if periods != 1 {
+		return nil, fmt.Errorf("Please specify exactly one of a month, week, quarter, year, date range or last days")
 	}
 
-	fmt.Println("## PRs:")
-	fmt.Println(userPRs)
-	fmt.Println()
-	fmt.Println("## Reviewed PRs:")
-	fmt.Println(reviewedPRs)
-	fmt.Println()
-	fmt.Println("## Issues:")
-	fmt.Println(userIssues)
-	fmt.Println()
-	fmt.Println("## Issues commented on:")
-	fmt.Println(commentIssues)
-	fmt.Println()
-	fmt.Println(userPRs.Links())
-	fmt.Println(reviewedPRs.Links())
-	fmt.Println(userIssues.Links())
-	fmt.Println(commentIssues.Links())
+	var err error
+	var period *Period
+	switch {
+	case monthly != "":
+		period, err = NewPeriodFromMonth(monthly)
+	case weekly != "":
+		period, err = NewPeriodFromWeek(weekly)
+	case quarterly != "":
+		period, err = NewPeriodFromQuarter(quarterly)
+	case yearly != "":
+		period, err = NewPeriodFromYear(yearly)
+	case last != "":
+		period, err = NewPeriodFromLast(last, time.Now())
+	default:
+		period, err = NewPeriodFromDates(from, to)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing period: %v", err)
+	}
+	return period, nil
+}
+
+// newClient creates a GitHub client. If baseURL is set, the client is
+// for the GitHub Enterprise instance with that API URL.
+func newClient(httpClient *http.Client, baseURL string) (*github.Client, error) {
+	if baseURL == "" {
+		return github.NewClient(httpClient), nil
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("%q is not an absolute URL", baseURL)
+	}
+	// Allow just the host to be specified
+	if u.Path == "" || u.Path == "/" {
+		u.Path = "/api/v3/"
+	}
+	uploadURL := *u
+	uploadURL.Path = strings.TrimSuffix(strings.TrimSuffix(u.Path, "/"), "/v3") + "/uploads/"
+	return github.NewEnterpriseClient(u.String(), uploadURL.String(), httpClient)
+}
+
+// appendUnique appends the strings in add to list unless they are already on it
+func appendUnique(list []string, add ...string) []string {
+	seen := make(map[string]bool)
+	for _, s := range list {
+		seen[s] = true
+	}
+	for _, s := range add {
+		if !seen[s] {
+			seen[s] = true
+			list = append(list, s)
+		}
+	}
+	return list
 }
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Durations summarises a list of durations
+type Durations struct {
+	Count  int
+	Median time.Duration
+	P90    time.Duration
+}
+
+// NewDurations computes the summary of a list of durations
+func NewDurations(ds []time.Duration) Durations {
+	d := Durations{Count: len(ds)}
+	if len(ds) == 0 {
+		return d
+	}
+	sort.Slice(ds, func(i, j int) bool { return ds[i] < ds[j] })
+	d.Median = percentile(ds, 0.5)
+	d.P90 = percentile(ds, 0.9)
+	return d
+}
+
+// percentile returns the p-th percentile of a sorted list of durations
+// using the nearest-rank method
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	n := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if n < 0 {
+		n = 0
+	}
+	return sorted[n]
+}
+
+func (d Durations) String() string {
+	if d.Count == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%s / %s (%d)", formatDuration(d.Median), formatDuration(d.P90), d.Count)
+}
+
+// formatDuration returns a short, human readable form of a duration
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	days := d / (24 * time.Hour)
+	hours := (d % (24 * time.Hour)) / time.Hour
+	minutes := (d % time.Hour) / time.Minute
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	}
+	return fmt.Sprintf("%dm", minutes)
+}
+
+// Metrics holds responsiveness metrics for a repository
+type Metrics struct {
+	// Repo is empty for the metrics across all repositories
+	Repo string
+	// FirstResponse is the time from opening a PR or Issue to the first
+	// comment or review by someone other than the author
+	FirstResponse Durations
+	// NoResponse counts the PRs and Issues without any response yet
+	NoResponse  int
+	TimeToMerge Durations
+	TimeToClose Durations
+}
+
+// metricsData collects the raw durations for Metrics
+type metricsData struct {
+	firstResponse []time.Duration
+	noResponse    int
+	merge         []time.Duration
+	close         []time.Duration
+}
+
+func (md *metricsData) add(period *Period, i *Item) {
+	if period.Match(i.CreatedAt) {
+		if t, ok := i.FirstResponse(); ok {
+			md.firstResponse = append(md.firstResponse, t.Sub(i.CreatedAt))
+		} else {
+			md.noResponse++
+		}
+	}
+	if i.PR && i.Merged && period.Match(i.MergedAt) {
+		md.merge = append(md.merge, i.MergedAt.Sub(i.CreatedAt))
+	}
+	if !i.PR && period.Match(i.ClosedAt) {
+		md.close = append(md.close, i.ClosedAt.Sub(i.CreatedAt))
+	}
+}
+
+func (md *metricsData) metrics(repo string) *Metrics {
+	return &Metrics{
+		Repo:          repo,
+		FirstResponse: NewDurations(md.firstResponse),
+		NoResponse:    md.noResponse,
+		TimeToMerge:   NewDurations(md.merge),
+		TimeToClose:   NewDurations(md.close),
+	}
+}
+
+// NewMetrics computes the metrics for each repository in the period.
+// The last entry holds the metrics across all repositories.
+func NewMetrics(repos []string, period *Period, items Items) []*Metrics {
+	perRepo := make(map[string]*metricsData)
+	for _, repo := range repos {
+		perRepo[repo] = &metricsData{}
+	}
+	all := &metricsData{}
+	for _, i := range items {
+		md, ok := perRepo[i.Repo]
+		if !ok {
+			continue
+		}
+		md.add(period, i)
+		all.add(period, i)
+	}
+
+	var ret []*Metrics
+	for _, repo := range repos {
+		ret = append(ret, perRepo[repo].metrics(repo))
+	}
+	return append(ret, all.metrics(""))
+}
+
+// FirstResponse returns the time of the first comment or review by
+// someone other than the author of the Item
+func (i *Item) FirstResponse() (time.Time, bool) {
+	var first time.Time
+	for _, c := range i.Comments {
+		if c.User == nil || (i.CreatedBy != nil && c.User.ID == i.CreatedBy.ID) {
+			continue
+		}
+		if first.IsZero() || c.CreatedAt.Before(first) {
+			first = c.CreatedAt
+		}
+	}
+	return first, !first.IsZero()
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxSearchQuery is roughly the maximum length of a search query
+// accepted by GitHub. Queries for many repositories are split up.
+const maxSearchQuery = 200
+
+// coreAssociations are author associations of users which are
+// certainly not newcomers
+var coreAssociations = map[string]bool{
+	"OWNER":        true,
+	"MEMBER":       true,
+	"COLLABORATOR": true,
+}
+
+// Newcomer is a user whose first contribution to the repositories falls
+// within the period of a report
+type Newcomer struct {
+	User *User
+	// First is the PR or Issue of the first contribution
+	First *Item
+	// Kind is "PR", "issue" or "comment"
+	Kind string
+	At   time.Time
+}
+
+// GetNewcomers finds the users whose first PR, Issue or comment in the
+// repositories was made in the period. items must contain all PRs and
+// Issues updated since the start of the period. Older activity is looked
+// up with the search API for users who are not known to be owners,
+// members or collaborators. The result is sorted by time of the first
+// contribution. Users whose earlier activity could not be looked up are
+// returned separately.
+func (f *Fetcher) GetNewcomers(ctx context.Context, repos []string, period *Period, items Items) ([]*Newcomer, []*User) {
+	first := make(map[string]*Newcomer)
+	core := make(map[string]bool)
+	record := func(u *User, i *Item, kind string, t time.Time) {
+		if u == nil {
+			return
+		}
+		if nc, ok := first[u.ID]; ok && !t.Before(nc.At) {
+			return
+		}
+		first[u.ID] = &Newcomer{User: u, First: i, Kind: kind, At: t}
+	}
+	for _, i := range items {
+		kind := "issue"
+		if i.PR {
+			kind = "PR"
+		}
+		record(i.CreatedBy, i, kind, i.CreatedAt)
+		if i.CreatedBy != nil && coreAssociations[i.AuthorAssociation] {
+			core[i.CreatedBy.ID] = true
+		}
+		for _, c := range i.Comments {
+			record(c.User, i, "comment", c.CreatedAt)
+			if c.User != nil && coreAssociations[c.AuthorAssociation] {
+				core[c.User.ID] = true
+			}
+		}
+	}
+
+	var ret []*Newcomer
+	var unchecked []*User
+	for id, nc := range first {
+		if core[id] || nc.User.Bot || !period.Match(nc.At) {
+			continue
+		}
+		old, err := f.hasActivityBefore(ctx, repos, id, period.Start)
+		if err != nil {
+			warnf("Error checking earlier activity of %s: %v\n", id, err)
+			f.addIncomplete("@"+id, fmt.Errorf("earlier activity: %v", err))
+			unchecked = append(unchecked, nc.User)
+			continue
+		}
+		if !old {
+			ret = append(ret, nc)
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if !ret[i].At.Equal(ret[j].At) {
+			return ret[i].At.Before(ret[j].At)
+		}
+		return ret[i].User.ID < ret[j].User.ID
+	})
+	sort.Slice(unchecked, func(i, j int) bool { return unchecked[i].ID < unchecked[j].ID })
+	return ret, unchecked
+}
+
+// hasActivityBefore returns true if the user created or commented on
+// PRs or Issues in the repositories which were last updated before t.
+// Activity on PRs and Issues updated since then is not covered.
+func (f *Fetcher) hasActivityBefore(ctx context.Context, repos []string, user string, t time.Time) (bool, error) {
+	for _, q := range repoQueries(repos) {
+		for _, role := range []string{"author", "commenter"} {
+			n, err := f.searchCount(ctx, fmt.Sprintf("%s %s:%s updated:<%s", q, role, user, t.Format(time.RFC3339)))
+			if err != nil {
+				return false, err
+			}
+			if n > 0 {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// repoQueries returns the repo: qualifiers for a search in the
+// repositories, split into several queries if needed
+func repoQueries(repos []string) []string {
+	var queries []string
+	var q []string
+	var l int
+	for _, repo := range repos {
+		if l > 0 && l+len(repo) > maxSearchQuery {
+			queries = append(queries, strings.Join(q, " "))
+			q = nil
+			l = 0
+		}
+		q = append(q, "repo:"+repo)
+		l += len(repo) + 6
+	}
+	if len(q) > 0 {
+		queries = append(queries, strings.Join(q, " "))
+	}
+	return queries
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/github"
+)
+
+// getNewcomers gets the newcomers of March 2018 in the fake repository
+func getNewcomers(t *testing.T, f *fakeGitHub) (*Fetcher, []*Newcomer, []*User) {
+	period, _ := NewPeriodFromMonth("2018-03")
+	fetcher := NewFetcher(f.Client(t), nil, 2)
+	users := make(Users)
+	prs, issues := fetcher.GetItems(context.Background(), []string{"o/r"}, "all", &period.Start, &users)
+	newcomers, unchecked := fetcher.GetNewcomers(context.Background(), []string{"o/r"}, period, append(prs, issues...))
+	return fetcher, newcomers, unchecked
+}
+
+func TestGetNewcomers(t *testing.T) {
+	defer fastRetries()()
+	f := newFakeRepo()
+	defer f.Close()
+	f.AddObject("/search/issues", &github.IssuesSearchResult{Total: github.Int(0)})
+	f.FailSecondary("/search/issues", 0, 1)
+
+	_, newcomers, unchecked := getNewcomers(t, f)
+	var got []string
+	for _, nc := range newcomers {
+		got = append(got, nc.User.ID)
+	}
+	// alice opened o/r#5 before March and bob is a member
+	if want := "dave carol erin"; strings.Join(got, " ") != want {
+		t.Errorf("got newcomers %v, want %s", got, want)
+	}
+	if len(unchecked) != 0 {
+		t.Errorf("got unchecked users %v", unchecked)
+	}
+	for _, r := range f.Requests() {
+		if strings.Contains(r, "/search/") && strings.Contains(r, "bob") {
+			t.Errorf("searched for the activity of a member: %s", r)
+		}
+	}
+}
+
+func TestGetNewcomersSearchFails(t *testing.T) {
+	defer fastRetries()()
+	f := newFakeRepo()
+	defer f.Close()
+	f.Fail("/search/issues", http.StatusInternalServerError, -1)
+
+	fetcher, newcomers, unchecked := getNewcomers(t, f)
+	if len(newcomers) != 0 {
+		t.Errorf("got newcomers %v, want none", newcomers)
+	}
+	var got []string
+	for _, u := range unchecked {
+		got = append(got, u.ID)
+	}
+	if want := "carol dave erin"; strings.Join(got, " ") != want {
+		t.Errorf("got unchecked users %v, want %s", got, want)
+	}
+	got = nil
+	for _, fe := range fetcher.Incomplete() {
+		got = append(got, fe.ID)
+	}
+	if want := "@carol @dave @erin"; strings.Join(got, " ") != want {
+		t.Errorf("got incomplete %v, want %s", got, want)
+	}
+}
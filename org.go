@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/google/go-github/github"
+)
+
+// RepoFilter selects which repositories of an organisation to report on
+type RepoFilter struct {
+	// Include and Exclude are glob patterns matched against the
+	// repository name (without the owner). If Include is empty all
+	// repositories are included.
+	Include []string
+	Exclude []string
+	// Archived and Forks include archived and forked repositories
+	Archived bool
+	Forks    bool
+}
+
+// Validate checks that all patterns of the filter are well formed
+func (rf *RepoFilter) Validate() error {
+	for _, pattern := range append(rf.Include, rf.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %v", pattern, err)
+		}
+	}
+	return nil
+}
+
+// Match returns true if the repository passes the filter
+func (rf *RepoFilter) Match(r *github.Repository) bool {
+	if r.GetArchived() && !rf.Archived {
+		return false
+	}
+	if r.GetFork() && !rf.Forks {
+		return false
+	}
+	name := r.GetName()
+	for _, pattern := range rf.Exclude {
+		if ok, _ := path.Match(pattern, name); ok {
+			return false
+		}
+	}
+	if len(rf.Include) == 0 {
+		return true
+	}
+	for _, pattern := range rf.Include {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// GetOrgRepos returns a sorted list of owner/repo names of all
+// repositories of an organisation which pass the filter
+func (f *Fetcher) GetOrgRepos(ctx context.Context, org string, filter *RepoFilter) ([]string, error) {
+	var repos []string
+	err := doListOp(func(page int) (*github.Response, error) {
+		opts := &github.RepositoryListByOrgOptions{Type: "all"}
+		opts.ListOptions.Page = page
+		ghRepos, resp, err := f.Client.Repositories.ListByOrg(ctx, org, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, ghRepo := range ghRepos {
+			if !filter.Match(ghRepo) {
+				debugf("  Skipping repository: %s\n", ghRepo.GetFullName())
+				continue
+			}
+			repos = append(repos, ghRepo.GetFullName())
+		}
+		return resp, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(repos)
+	return repos, nil
+}
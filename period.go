@@ -11,6 +11,9 @@ import (
 type Period struct {
 	Start time.Time
 	End   time.Time
+
+	// The length of the period in calendar units, used by Previous()
+	years, months, days int
 }
 
 func (p *Period) String() string {
@@ -22,6 +25,21 @@ func (p *Period) Match(t time.Time) bool {
 	return t.After(p.Start) && t.Before(p.End)
 }
 
+// Previous returns the period of the same length before p, e.g. the
+// previous month for a month
+func (p *Period) Previous() *Period {
+	if p.years == 0 && p.months == 0 && p.days == 0 {
+		d := p.End.Sub(p.Start)
+		return &Period{Start: p.Start.Add(-d), End: p.Start}
+	}
+	prev := &Period{years: p.years, months: p.months, days: p.days}
+	prev.Start = p.Start.AddDate(-p.years, -p.months, -p.days)
+	// Keep the distance of End to the start of the next period
+	offset := p.End.Sub(p.Start.AddDate(p.years, p.months, p.days))
+	prev.End = p.Start.Add(offset)
+	return prev
+}
+
 // daysIn returns the number of days in a month for a given year.
 // From: https://groups.google.com/forum/#!topic/golang-nuts/W-ezk71hioo
 func daysIn(year int, m time.Month) int {
@@ -29,20 +47,45 @@ func daysIn(year int, m time.Month) int {
 	return time.Date(year, m+1, 0, 0, 0, 0, 0, time.UTC).Day()
 }
 
+// splitPair splits a string of the form a-b, as used for months, weeks
+// and quarters, into its two parts
+func splitPair(in, format string) (string, string, error) {
+	o := strings.SplitN(in, "-", 2)
+	if len(o) != 2 || o[0] == "" || o[1] == "" {
+		return "", "", fmt.Errorf("%q is not of the form %s", in, format)
+	}
+	return o[0], o[1], nil
+}
+
+// parseYear parses a year and makes sure it is somewhat sensible
+func parseYear(in string) (int, error) {
+	year, err := strconv.Atoi(in)
+	if err != nil {
+		return 0, fmt.Errorf("invalid year %q", in)
+	}
+	if year < 1970 || year > 9999 {
+		return 0, fmt.Errorf("year %d out of range", year)
+	}
+	return year, nil
+}
+
 // NewPeriodFromMonth coverts a string of the form month-year into a period with the start/end of the month
 func NewPeriodFromMonth(in string) (*Period, error) {
-	o := strings.SplitN(in, "-", 2)
-	year, err := strconv.Atoi(o[0])
+	y, m, err := splitPair(in, "YYYY-MM")
 	if err != nil {
 		return nil, err
 	}
-	m, err := strconv.Atoi(o[1])
+	year, err := parseYear(y)
 	if err != nil {
 		return nil, err
 	}
-	month := time.Month(m)
+	mon, err := strconv.Atoi(m)
+	if err != nil || mon < 1 || mon > 12 {
+		return nil, fmt.Errorf("invalid month %q", m)
+	}
+	month := time.Month(mon)
 
-	p := &Period{}
+	p := &Period{months: 1}
 	p.Start = time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
 	p.End = time.Date(year, month, daysIn(year, month), 23, 59, 59, 0, time.UTC)
 	return p, nil
@@ -68,20 +111,111 @@ func firstDayOfISOWeek(year int, week int) time.Time {
 	return date
 }
 
+// isoWeeksIn returns the number of ISO weeks in a year.
+// December 28th is always in the last week of the year.
+func isoWeeksIn(year int) int {
+	_, weeks := time.Date(year, time.December, 28, 0, 0, 0, 0, time.UTC).ISOWeek()
+	return weeks
+}
+
 // NewPeriodFromWeek coverts a string of the form week-year into a period with the start/end of the month
 func NewPeriodFromWeek(in string) (*Period, error) {
-	o := strings.SplitN(in, "-", 2)
-	year, err := strconv.Atoi(o[0])
+	y, w, err := splitPair(in, "YYYY-WW")
 	if err != nil {
 		return nil, err
 	}
-	week, err := strconv.Atoi(o[1])
+	year, err := parseYear(y)
 	if err != nil {
 		return nil, err
 	}
+	week, err := strconv.Atoi(w)
+	if err != nil || week < 1 || week > isoWeeksIn(year) {
+		return nil, fmt.Errorf("invalid week %q for %d", w, year)
+	}
 
-	p := &Period{}
+	p := &Period{days: 7}
 	p.Start = firstDayOfISOWeek(year, week)
 	p.End = p.Start.AddDate(0, 0, 7)
 	return p, nil
 }
+
+// NewPeriodFromQuarter converts a string of the form year-Qn into a period with the start/end of the quarter
+func NewPeriodFromQuarter(in string) (*Period, error) {
+	y, q, err := splitPair(in, "YYYY-Qn")
+	if err != nil {
+		return nil, err
+	}
+	year, err := parseYear(y)
+	if err != nil {
+		return nil, err
+	}
+	if len(q) != 2 || (q[0] != 'Q' && q[0] != 'q') || q[1] < '1' || q[1] > '4' {
+		return nil, fmt.Errorf("invalid quarter %q", q)
+	}
+	first := time.Month(3*int(q[1]-'1') + 1)
+	last := first + 2
+
+	p := &Period{months: 3}
+	p.Start = time.Date(year, first, 1, 0, 0, 0, 0, time.UTC)
+	p.End = time.Date(year, last, daysIn(year, last), 23, 59, 59, 0, time.UTC)
+	return p, nil
+}
+
+// NewPeriodFromYear converts a string with a year into a period with the start/end of the year
+func NewPeriodFromYear(in string) (*Period, error) {
+	year, err := parseYear(in)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Period{years: 1}
+	p.Start = time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	p.End = time.Date(year, time.December, 31, 23, 59, 59, 0, time.UTC)
+	return p, nil
+}
+
+// NewPeriodFromDates converts two dates of the form year-month-day into
+// a period from the start of the first to the end of the second date
+func NewPeriodFromDates(from, to string) (*Period, error) {
+	start, err := time.Parse("2006-01-02", from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", from)
+	}
+	end, err := time.Parse("2006-01-02", to)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", to)
+	}
+	if end.Before(start) {
+		return nil, fmt.Errorf("%s is before %s", to, from)
+	}
+
+	p := &Period{days: int(end.Sub(start)/(24*time.Hour)) + 1}
+	p.Start = start
+	p.End = end.Add(24*time.Hour - time.Second)
+	return p, nil
+}
+
+// NewPeriodFromLast converts a string of the form Nd or Nw into a period
+// covering the last N days or weeks up to today. Today counts as one day.
+func NewPeriodFromLast(in string, now time.Time) (*Period, error) {
+	if len(in) < 2 {
+		return nil, fmt.Errorf("%q is not of the form Nd or Nw", in)
+	}
+	n, err := strconv.Atoi(in[:len(in)-1])
+	if err != nil || n < 1 {
+		return nil, fmt.Errorf("%q is not of the form Nd or Nw", in)
+	}
+	switch in[len(in)-1] {
+	case 'd':
+	case 'w':
+		n *= 7
+	default:
+		return nil, fmt.Errorf("%q is not of the form Nd or Nw", in)
+	}
+
+	now = now.UTC()
+	p := &Period{days: n}
+	p.Start = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1-n)
+	p.End = time.Date(now.Year(), now.Month(), now.Day(), 23, 59, 59, 0, time.UTC)
+	return p, nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPeriodMatch(t *testing.T) {
+	p, err := NewPeriodFromMonth("2018-03")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		t    string
+		want bool
+	}{
+		{"2018-02-28T23:59:59Z", false},
+		{"2018-03-01T00:00:01Z", true},
+		{"2018-03-15T12:00:00Z", true},
+		{"2018-03-31T23:59:58Z", true},
+		{"2018-04-01T00:00:00Z", false},
+		{"0001-01-01T00:00:00Z", false},
+	} {
+		if got := p.Match(*ghTime(tc.t)); got != tc.want {
+			t.Errorf("Match(%s) = %t, want %t", tc.t, got, tc.want)
+		}
+	}
+}
+
+func TestFirstDayOfISOWeek(t *testing.T) {
+	for _, tc := range []struct {
+		year, week int
+		want       string
+	}{
+		{2018, 1, "2018-01-01"},
+		{2018, 8, "2018-02-19"},
+		{2015, 1, "2014-12-29"},
+		{2015, 53, "2015-12-28"},
+		{2021, 1, "2021-01-04"},
+		{2020, 53, "2020-12-28"},
+	} {
+		got := firstDayOfISOWeek(tc.year, tc.week)
+		if got.Format("2006-01-02") != tc.want {
+			t.Errorf("firstDayOfISOWeek(%d, %d) = %s, want %s", tc.year, tc.week, got.Format("2006-01-02"), tc.want)
+		}
+		if got.Weekday() != time.Monday {
+			t.Errorf("firstDayOfISOWeek(%d, %d) is a %s", tc.year, tc.week, got.Weekday())
+		}
+	}
+}
+
+func TestNewPeriod(t *testing.T) {
+	now := *ghTime("2018-03-20T15:04:05Z")
+	for _, tc := range []struct {
+		name string
+		new  func() (*Period, error)
+		want string
+	}{
+		{"month", func() (*Period, error) { return NewPeriodFromMonth("2018-02") }, "2018-02-01 to 2018-02-28"},
+		{"week", func() (*Period, error) { return NewPeriodFromWeek("2018-08") }, "2018-02-19 to 2018-02-26"},
+		{"quarter", func() (*Period, error) { return NewPeriodFromQuarter("2018-Q2") }, "2018-04-01 to 2018-06-30"},
+		{"year", func() (*Period, error) { return NewPeriodFromYear("2018") }, "2018-01-01 to 2018-12-31"},
+		{"dates", func() (*Period, error) { return NewPeriodFromDates("2018-03-05", "2018-03-19") }, "2018-03-05 to 2018-03-19"},
+		{"last", func() (*Period, error) { return NewPeriodFromLast("2w", now) }, "2018-03-07 to 2018-03-20"},
+	} {
+		p, err := tc.new()
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if p.String() != tc.want {
+			t.Errorf("%s: got %s, want %s", tc.name, p, tc.want)
+		}
+	}
+
+	for _, in := range []string{"2018-13", "2018", "18-01"} {
+		if _, err := NewPeriodFromMonth(in); err == nil {
+			t.Errorf("NewPeriodFromMonth(%q) did not fail", in)
+		}
+	}
+	if _, err := NewPeriodFromWeek("2018-53"); err == nil {
+		t.Errorf("2018 has no week 53")
+	}
+}
+
+func TestPeriodPrevious(t *testing.T) {
+	p, _ := NewPeriodFromMonth("2018-03")
+	if got := p.Previous().String(); got != "2018-02-01 to 2018-02-28" {
+		t.Errorf("previous month is %s", got)
+	}
+	p, _ = NewPeriodFromWeek("2018-01")
+	if got := p.Previous().String(); got != "2017-12-25 to 2018-01-01" {
+		t.Errorf("previous week is %s", got)
+	}
+}
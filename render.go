@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Report is a computed report which can be rendered in different formats
+type Report interface {
+	// Markdown writes the report as markdown fragments to w
+	Markdown(w io.Writer) error
+	// HTML writes the report as a self-contained HTML page to w
+	HTML(w io.Writer) error
+}
+
+// Supported output formats
+const (
+	FormatMarkdown = "markdown"
+	FormatJSON     = "json"
+	FormatHTML     = "html"
+)
+
+// Render writes the report to w in the requested format
+func Render(w io.Writer, r Report, format string) error {
+	switch format {
+	case FormatMarkdown:
+		return r.Markdown(w)
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	case FormatHTML:
+		return r.HTML(w)
+	}
+	return fmt.Errorf("unknown output format: %s", format)
+}
+
+// Markdown writes the repository report as markdown fragments to w
+func (r *RepoReport) Markdown(w io.Writer) error {
+	return markdownTemplates.ExecuteTemplate(w, "repo-report", r)
+}
+
+// Markdown writes the user report as markdown fragments to w
+func (r *UserReport) Markdown(w io.Writer) error {
+	return markdownTemplates.ExecuteTemplate(w, "user-report", r)
+}
+
+// Markdown writes the team report as markdown fragments to w
+func (r *TeamReport) Markdown(w io.Writer) error {
+	return markdownTemplates.ExecuteTemplate(w, "team-report", r)
+}
+
+// Markdown writes the changelog as markdown fragments to w
+func (cl *Changelog) Markdown(w io.Writer) error {
+	return markdownTemplates.ExecuteTemplate(w, "changelog", cl)
+}
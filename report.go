@@ -0,0 +1,322 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Summary holds the counters of a repository report
+type Summary struct {
+	Contributions int
+	Contributors  int
+	OpenedPRs     int
+	MergedPRs     int
+	OpenedIssues  int
+	ClosedIssues  int
+}
+
+// RepoReport is the computed report about activity on Repositories
+type RepoReport struct {
+	// BaseURL is the URL of the GitHub web interface
+	BaseURL      string
+	Period       *Period
+	Repos        []string
+	Summary      Summary
+	MergedPRs    Items
+	ClosedIssues Items
+	UpdatedItems Items
+	// Metrics per repository, followed by the metrics across all of them
+	Metrics []*Metrics
+	// Reviews holds the review statistics of the PRs
+	Reviews *ReviewStats
+	// Sizes holds the size statistics of the merged PRs
+	Sizes *PRSizes
+	// LabelCounts holds the opened/closed counts per label
+	LabelCounts []*LabelCount
+	// GroupLabel is the label prefix MergedPRs and ClosedIssues are
+	// grouped by. The groups are empty unless it is set.
+	GroupLabel        string
+	MergedPRGroups    []*ItemGroup
+	ClosedIssueGroups []*ItemGroup
+	// Newcomers are only set if requested
+	Newcomers []*Newcomer
+	// NewcomersUnchecked are the possible newcomers whose earlier
+	// activity could not be looked up
+	NewcomersUnchecked []*User
+	// Commits is only set if requested
+	Commits *CommitActivity
+	// Backlog is only set if requested
+	Backlog *Backlog
+	// Trend holds the counters of the preceding periods, most recent first
+	Trend []*TrendEntry
+	// BotActivity is only set if bots are reported separately
+	BotActivity []*BotActivity
+	// Users contains all users which may be linked in Issues/PRs
+	Users Users
+}
+
+// NewRepoReport computes a report about activity on Repositories
+func NewRepoReport(baseURL string, repos []string, period *Period, allPRs, allIssues Items) *RepoReport {
+	r := &RepoReport{
+		BaseURL:           baseURL,
+		Period:            period,
+		Repos:             repos,
+		MergedPRs:         Items{},
+		ClosedIssues:      Items{},
+		UpdatedItems:      Items{},
+		MergedPRGroups:    []*ItemGroup{},
+		ClosedIssueGroups: []*ItemGroup{},
+		Users:             make(Users),
+	}
+	contributors := make(Users)
+
+	for _, i := range append(allPRs, allIssues...) {
+		infof("Processing: %s\n", i)
+		debugf("%s\n\n", i.Dump())
+
+		var updated bool
+
+		// Record all users as they may be linked in Issues/PRs
+		r.Users[i.CreatedBy.ID] = i.CreatedBy
+
+		// Handle comments first
+		for _, comment := range i.Comments {
+			if period.Match(comment.CreatedAt) {
+				r.Summary.Contributions++
+				contributors[comment.User.ID] = comment.User
+				updated = true
+			}
+			// Record all users as they may be linked in Issues/PRs
+			r.Users[comment.User.ID] = comment.User
+		}
+
+		// Next handle contributions from new Items
+		if period.Match(i.CreatedAt) {
+			updated = true
+			// Only count contributor here. Item will be put on the appropriate list below
+			r.Summary.Contributions++
+			contributors[i.CreatedBy.ID] = i.CreatedBy
+			if i.PR {
+				r.Summary.OpenedPRs++
+			} else {
+				r.Summary.OpenedIssues++
+			}
+		}
+
+		// Next handle closed PRs and issues
+		if period.Match(i.ClosedAt) {
+			if i.PR {
+				if i.Merged {
+					r.MergedPRs = append(r.MergedPRs, i)
+					// Sigh...sometimes MergedBy is not filled in
+					if i.MergedBy != nil {
+						r.Users[i.MergedBy.ID] = i.MergedBy
+						contributors[i.MergedBy.ID] = i.MergedBy
+					}
+				} else {
+					// PR was *not* merged. Count as updated
+					r.UpdatedItems = append(r.UpdatedItems, i)
+				}
+			} else {
+				// Issues, just add to closed issues list
+				r.ClosedIssues = append(r.ClosedIssues, i)
+			}
+		} else {
+			if updated {
+				// Not closed, but updated, so add to updated list
+				// Contributions were already counted.
+				r.UpdatedItems = append(r.UpdatedItems, i)
+			}
+		}
+	}
+
+	r.Metrics = NewMetrics(repos, period, append(allPRs, allIssues...))
+	r.Reviews = NewReviewStats(period, allPRs)
+	r.Sizes = NewPRSizes(period, allPRs)
+	r.LabelCounts = NewLabelCounts(period, append(allPRs, allIssues...))
+
+	r.Summary.Contributors = len(contributors)
+	r.Summary.MergedPRs = len(r.MergedPRs)
+	r.Summary.ClosedIssues = len(r.ClosedIssues)
+	return r
+}
+
+// GroupByLabel groups the merged PRs and closed Issues by their labels
+// starting with prefix instead of by repository
+func (r *RepoReport) GroupByLabel(prefix string) {
+	r.GroupLabel = prefix
+	r.MergedPRGroups = r.MergedPRs.GroupByLabel(prefix)
+	r.ClosedIssueGroups = r.ClosedIssues.GroupByLabel(prefix)
+}
+
+// Links returns the markdown style links to the repositories, Items,
+// commits and users the report refers to, each only once
+func (r *RepoReport) Links() string {
+	l := newLinkSet()
+	l.addItems(r.MergedPRs, r.ClosedIssues, r.UpdatedItems)
+	for _, u := range r.Users {
+		l.addUsers(u)
+	}
+	if r.Reviews != nil {
+		for _, s := range r.Reviews.Reviewers {
+			l.addUsers(s.User)
+		}
+		l.addItems(r.Reviews.Unapproved)
+	}
+	if r.Sizes != nil {
+		l.addItems(r.Sizes.Largest)
+	}
+	var commits Commits
+	if r.Commits != nil {
+		commits = r.Commits.Direct
+		for _, c := range commits {
+			l.addUsers(c.Author)
+		}
+	}
+	for _, nc := range r.Newcomers {
+		l.addItems(Items{nc.First})
+	}
+	l.addUsers(r.NewcomersUnchecked...)
+	if r.Backlog != nil {
+		l.addItems(r.Backlog.Stale, r.Backlog.Unanswered)
+	}
+	l.addItems(r.BotItems())
+	for _, ba := range r.BotActivity {
+		l.addUsers(ba.User)
+	}
+
+	var links []string
+	for _, repo := range r.Repos {
+		links = append(links, fmt.Sprintf("[%s]: %s%s", repo, r.BaseURL, repo))
+	}
+	if len(commits) > 0 {
+		links = append(links, commits.Links())
+	}
+	return strings.Join(append(links, l.String()), "\n")
+}
+
+// BotItems returns the Items with bot activity
+func (r *RepoReport) BotItems() Items {
+	var items Items
+	seen := make(map[*Item]bool)
+	for _, ba := range r.BotActivity {
+		for _, i := range ba.Items {
+			if !seen[i] {
+				seen[i] = true
+				items = append(items, i)
+			}
+		}
+	}
+	return items
+}
+
+// UserSummary holds the counters of a user report
+type UserSummary struct {
+	OpenedPRs int
+	// MergedPRs counts the PRs of the user merged in the period,
+	// including PRs opened before it
+	MergedPRs int
+	// Reviews counts the reviews and ReviewComments the line comments
+	// the user submitted on PRs of others
+	Reviews         int
+	ReviewComments  int
+	OpenedIssues    int
+	CommentedIssues int
+}
+
+// UserReport is the computed report about activity of a single user
+type UserReport struct {
+	// BaseURL is the URL of the GitHub web interface
+	BaseURL         string
+	Period          *Period
+	Repos           []string
+	User            string
+	Summary         UserSummary
+	PRs             Items
+	ReviewedPRs     Items
+	Issues          Items
+	CommentedIssues Items
+	// Users contains all users involved with the Items of the report
+	Users Users
+}
+
+// NewUserReport computes a report about activity of a single user
+func NewUserReport(baseURL string, repos []string, period *Period, user string, allPRs, allIssues Items) *UserReport {
+	r := &UserReport{
+		BaseURL:         baseURL,
+		Period:          period,
+		Repos:           repos,
+		User:            user,
+		PRs:             Items{},
+		ReviewedPRs:     Items{},
+		Issues:          Items{},
+		CommentedIssues: Items{},
+		Users:           make(Users),
+	}
+
+	for _, pr := range allPRs {
+		if pr.CreatedBy.ID == user && pr.Merged && period.Match(pr.MergedAt) {
+			r.Summary.MergedPRs++
+		}
+		if period.Match(pr.CreatedAt) && pr.CreatedBy.ID == user {
+			r.PRs = append(r.PRs, pr)
+			continue
+		}
+		if pr.CreatedBy.ID != user {
+			for _, comment := range pr.Comments {
+				if !period.Match(comment.CreatedAt) || comment.User.ID != user {
+					continue
+				}
+				switch comment.Kind {
+				case CommentReview:
+					r.Summary.Reviews++
+				case CommentReviewComment:
+					r.Summary.ReviewComments++
+				}
+			}
+		}
+		if period.Match(pr.ClosedAt) && pr.MergedBy != nil && pr.MergedBy.ID == user {
+			r.ReviewedPRs = append(r.ReviewedPRs, pr)
+			continue
+		}
+		for _, comment := range pr.Comments {
+			if period.Match(comment.CreatedAt) && comment.User.ID == user {
+				r.ReviewedPRs = append(r.ReviewedPRs, pr)
+				break
+			}
+		}
+	}
+	for _, i := range allIssues {
+		if period.Match(i.CreatedAt) && i.CreatedBy.ID == user {
+			r.Issues = append(r.Issues, i)
+			continue
+		}
+		for _, comment := range i.Comments {
+			if period.Match(comment.CreatedAt) && comment.User.ID == user {
+				r.CommentedIssues = append(r.CommentedIssues, i)
+				break
+			}
+		}
+	}
+
+	for _, list := range []Items{r.PRs, r.ReviewedPRs, r.Issues, r.CommentedIssues} {
+		for _, i := range list {
+			for _, u := range i.Participants() {
+				r.Users[u.ID] = u
+			}
+		}
+	}
+
+	r.Summary.OpenedPRs = len(r.PRs)
+	r.Summary.OpenedIssues = len(r.Issues)
+	r.Summary.CommentedIssues = len(r.CommentedIssues)
+	return r
+}
+
+// Links returns the markdown style links to the Items and users the
+// report refers to, each only once
+func (r *UserReport) Links() string {
+	l := newLinkSet()
+	l.addItems(r.PRs, r.ReviewedPRs, r.Issues, r.CommentedIssues)
+	return l.String()
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testItems returns PRs and Issues covering all cases of the
+// classification for March 2018
+func testItems() (Items, Items) {
+	a := &User{ID: "alice"}
+	b := &User{ID: "bob"}
+	c := &User{ID: "carol"}
+	at := func(s string) time.Time { return *ghTime(s + "T10:00:00Z") }
+
+	prs := Items{
+		// Opened and merged
+		{PR: true, ID: "o/r#1", Repo: "o/r", Number: 1, CreatedBy: a, CreatedAt: at("2018-03-02"),
+			ClosedAt: at("2018-03-05"), Merged: true, MergedAt: at("2018-03-05"), MergedBy: b,
+			Comments: []*Comment{{User: b, CreatedAt: at("2018-03-03"), Kind: CommentReview, State: ReviewApproved}}},
+		// Closed without merging
+		{PR: true, ID: "o/r#2", Repo: "o/r", Number: 2, CreatedBy: b, CreatedAt: at("2018-02-02"),
+			ClosedAt: at("2018-03-06")},
+		// Commented on, still open
+		{PR: true, ID: "o/r#3", Repo: "o/r", Number: 3, CreatedBy: c, CreatedAt: at("2018-02-03"),
+			Comments: []*Comment{{User: b, CreatedAt: at("2018-02-04"), Kind: CommentReviewComment}, {User: a, CreatedAt: at("2018-03-07"), Kind: CommentReviewComment}}},
+		// No activity in the period
+		{PR: true, ID: "o/r#4", Repo: "o/r", Number: 4, CreatedBy: a, CreatedAt: at("2018-02-04"),
+			Comments: []*Comment{{User: b, CreatedAt: at("2018-02-05"), Kind: CommentReview, State: ReviewCommented}}},
+	}
+	issues := Items{
+		// Opened and closed
+		{ID: "o/r#5", Repo: "o/r", Number: 5, CreatedBy: c, CreatedAt: at("2018-03-08"), ClosedAt: at("2018-03-09"),
+			Comments: []*Comment{{User: b, CreatedAt: at("2018-03-08"), Kind: CommentIssue}}},
+		// Opened before, closed
+		{ID: "o/r#6", Repo: "o/r", Number: 6, CreatedBy: a, CreatedAt: at("2018-01-06"), ClosedAt: at("2018-03-10")},
+		// Opened, still open
+		{ID: "o/r#7", Repo: "o/r", Number: 7, CreatedBy: b, CreatedAt: at("2018-03-11")},
+	}
+	return prs, issues
+}
+
+func ids(items Items) string {
+	var s []string
+	for _, i := range items {
+		s = append(s, i.ID)
+	}
+	return strings.Join(s, " ")
+}
+
+func TestNewRepoReport(t *testing.T) {
+	p, _ := NewPeriodFromMonth("2018-03")
+	prs, issues := testItems()
+	r := NewRepoReport("https://github.com/", []string{"o/r"}, p, prs, issues)
+
+	if got := ids(r.MergedPRs); got != "o/r#1" {
+		t.Errorf("merged PRs: %s", got)
+	}
+	if got := ids(r.ClosedIssues); got != "o/r#5 o/r#6" {
+		t.Errorf("closed Issues: %s", got)
+	}
+	if got := ids(r.UpdatedItems); got != "o/r#2 o/r#3 o/r#7" {
+		t.Errorf("updated Items: %s", got)
+	}
+	want := Summary{
+		// 3 PRs and Issues opened, 3 comments and reviews
+		Contributions: 6,
+		Contributors:  3,
+		OpenedPRs:     1,
+		MergedPRs:     1,
+		OpenedIssues:  2,
+		ClosedIssues:  2,
+	}
+	if r.Summary != want {
+		t.Errorf("got summary %+v, want %+v", r.Summary, want)
+	}
+	if len(r.Users) != 3 {
+		t.Errorf("got %d users, want 3", len(r.Users))
+	}
+}
+
+func TestNewUserReport(t *testing.T) {
+	p, _ := NewPeriodFromMonth("2018-03")
+	prs, issues := testItems()
+
+	for _, tc := range []struct {
+		user                             string
+		prs, reviewed, issues, commented string
+		summary                          UserSummary
+	}{
+		// alice only left a line comment and bob only approved
+		{"alice", "o/r#1", "o/r#3", "", "", UserSummary{OpenedPRs: 1, MergedPRs: 1, ReviewComments: 1}},
+		{"bob", "", "o/r#1", "o/r#7", "o/r#5", UserSummary{Reviews: 1, OpenedIssues: 1, CommentedIssues: 1}},
+		{"carol", "", "", "o/r#5", "", UserSummary{OpenedIssues: 1}},
+	} {
+		r := NewUserReport("https://github.com/", []string{"o/r"}, p, tc.user, prs, issues)
+		if got := ids(r.PRs); got != tc.prs {
+			t.Errorf("%s PRs: %s, want %s", tc.user, got, tc.prs)
+		}
+		if got := ids(r.ReviewedPRs); got != tc.reviewed {
+			t.Errorf("%s reviewed PRs: %s, want %s", tc.user, got, tc.reviewed)
+		}
+		if got := ids(r.Issues); got != tc.issues {
+			t.Errorf("%s Issues: %s, want %s", tc.user, got, tc.issues)
+		}
+		if got := ids(r.CommentedIssues); got != tc.commented {
+			t.Errorf("%s commented Issues: %s, want %s", tc.user, got, tc.commented)
+		}
+		if r.Summary != tc.summary {
+			t.Errorf("%s summary: %+v, want %+v", tc.user, r.Summary, tc.summary)
+		}
+	}
+}
+
+// checkNoNullLists checks that the lists of a report are encoded as []
+// rather than null in its JSON if they are empty
+func checkNoNullLists(t *testing.T, r Report, lists ...string) {
+	var buf bytes.Buffer
+	if err := Render(&buf, r, FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	for _, list := range lists {
+		if strings.Contains(buf.String(), `"`+list+`": null`) {
+			t.Errorf("empty %s are not encoded as []:\n%s", list, buf.String())
+		}
+	}
+}
+
+func TestRepoReportJSON(t *testing.T) {
+	// A quiet period
+	p, _ := NewPeriodFromMonth("2018-05")
+	prs, issues := testItems()
+
+	r := NewRepoReport("https://github.com/", []string{"o/r"}, p, prs, issues)
+	checkNoNullLists(t, r, "MergedPRs", "ClosedIssues", "UpdatedItems", "Reviewers", "Unapproved",
+		"Largest", "MergedPRGroups", "ClosedIssueGroups")
+}
+
+func TestTeamReportJSON(t *testing.T) {
+	p, _ := NewPeriodFromMonth("2018-03")
+	prs, issues := testItems()
+
+	r := NewTeamReport("https://github.com/", []string{"o/r"}, p, "", []string{"carol", "dave"}, prs, issues)
+	if len(r.Users) != 2 || r.Users["carol"] == nil || r.Users["bob"] == nil {
+		t.Errorf("got users %v, want the participants of o/r#5", r.Users)
+	}
+	checkNoNullLists(t, r, "PRs", "ReviewedPRs", "Issues", "CommentedIssues")
+}
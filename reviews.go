@@ -0,0 +1,115 @@
+package main
+
+import (
+	"sort"
+)
+
+// Review states reported by GitHub
+const (
+	ReviewApproved         = "APPROVED"
+	ReviewChangesRequested = "CHANGES_REQUESTED"
+	ReviewCommented        = "COMMENTED"
+)
+
+// ReviewerStats counts the reviews a user submitted in a period
+type ReviewerStats struct {
+	User             *User
+	Approvals        int
+	ChangesRequested int
+	// Comments counts reviews which neither approved nor requested
+	// changes and LineComments the comments on lines of a PR
+	Comments     int
+	LineComments int
+}
+
+// ReviewStats describes the reviews of PRs in a period
+type ReviewStats struct {
+	// Reviewers is sorted by ID
+	Reviewers []*ReviewerStats
+	// Unapproved lists the PRs merged in the period without an
+	// approving review by someone other than the author
+	Unapproved Items
+	// Merged is the number of PRs merged in the period and AvgRounds
+	// the average number of review rounds per merged PR
+	Merged    int
+	AvgRounds float64
+}
+
+// reviewRounds returns the number of review rounds of a PR before it was
+// merged. Every reviewer takes part in a round once, so the number of
+// rounds is the largest number of reviews by a single reviewer.
+func (i *Item) reviewRounds() int {
+	reviews := make(map[string]int)
+	var rounds int
+	for _, c := range i.Comments {
+		if c.Kind != CommentReview || c.User.ID == i.CreatedBy.ID || c.CreatedAt.After(i.MergedAt) {
+			continue
+		}
+		reviews[c.User.ID]++
+		if reviews[c.User.ID] > rounds {
+			rounds = reviews[c.User.ID]
+		}
+	}
+	return rounds
+}
+
+// approved returns true if someone other than the author approved the PR
+func (i *Item) approved() bool {
+	for _, c := range i.Comments {
+		if c.Kind == CommentReview && c.State == ReviewApproved && c.User.ID != i.CreatedBy.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// NewReviewStats computes the review statistics of the PRs for a period.
+// Reviews of authors on their own PRs are not counted.
+func NewReviewStats(period *Period, prs Items) *ReviewStats {
+	rs := &ReviewStats{Reviewers: []*ReviewerStats{}, Unapproved: Items{}}
+	reviewers := make(map[string]*ReviewerStats)
+	var rounds int
+	for _, pr := range prs {
+		for _, c := range pr.Comments {
+			if !period.Match(c.CreatedAt) || c.User.ID == pr.CreatedBy.ID {
+				continue
+			}
+			if c.Kind != CommentReview && c.Kind != CommentReviewComment {
+				continue
+			}
+			s, ok := reviewers[c.User.ID]
+			if !ok {
+				s = &ReviewerStats{User: c.User}
+				reviewers[c.User.ID] = s
+			}
+			switch {
+			case c.Kind == CommentReviewComment:
+				s.LineComments++
+			case c.State == ReviewApproved:
+				s.Approvals++
+			case c.State == ReviewChangesRequested:
+				s.ChangesRequested++
+			default:
+				s.Comments++
+			}
+		}
+
+		if !pr.Merged || !period.Match(pr.MergedAt) {
+			continue
+		}
+		rs.Merged++
+		rounds += pr.reviewRounds()
+		if !pr.approved() {
+			rs.Unapproved = append(rs.Unapproved, pr)
+		}
+	}
+
+	for _, s := range reviewers {
+		rs.Reviewers = append(rs.Reviewers, s)
+	}
+	sort.Slice(rs.Reviewers, func(i, j int) bool { return rs.Reviewers[i].User.ID < rs.Reviewers[j].User.ID })
+	if rs.Merged > 0 {
+		rs.AvgRounds = float64(rounds) / float64(rs.Merged)
+	}
+	return rs
+}
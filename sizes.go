@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// maxLargest is the number of largest merged PRs listed
+const maxLargest = 5
+
+// sizeBuckets are the upper bounds (in lines changed) of the PR size
+// histogram
+var sizeBuckets = []struct {
+	Label string
+	Lines int
+}{
+	{"XS", 10},
+	{"S", 30},
+	{"M", 100},
+	{"L", 500},
+	{"XL", 0},
+}
+
+// SizeBucket counts the PRs of a size range
+type SizeBucket struct {
+	Label string
+	// Lines is the range of lines changed, e.g. 10-29
+	Lines string
+	PRs   int
+}
+
+// PRSizes describes the size of the PRs merged in a period
+type PRSizes struct {
+	Merged    int
+	Additions int
+	Deletions int
+	// Buckets is a histogram of the lines changed by the merged PRs
+	Buckets []*SizeBucket
+	// Largest lists the merged PRs with the most lines changed
+	Largest Items
+}
+
+// Lines returns the number of lines a PR changed
+func (i *Item) Lines() int {
+	return i.Additions + i.Deletions
+}
+
+// NewPRSizes computes the size statistics of the PRs merged in a period
+func NewPRSizes(period *Period, prs Items) *PRSizes {
+	s := &PRSizes{}
+	from := 0
+	for _, sb := range sizeBuckets {
+		b := &SizeBucket{Label: sb.Label}
+		if sb.Lines == 0 {
+			b.Lines = fmt.Sprintf("%d+", from)
+		} else {
+			b.Lines = fmt.Sprintf("%d-%d", from, sb.Lines-1)
+		}
+		s.Buckets = append(s.Buckets, b)
+		from = sb.Lines
+	}
+
+	merged := Items{}
+	for _, pr := range prs {
+		if !pr.Merged || !period.Match(pr.MergedAt) {
+			continue
+		}
+		merged = append(merged, pr)
+		s.Additions += pr.Additions
+		s.Deletions += pr.Deletions
+		for n, sb := range sizeBuckets {
+			if sb.Lines == 0 || pr.Lines() < sb.Lines {
+				s.Buckets[n].PRs++
+				break
+			}
+		}
+	}
+	s.Merged = len(merged)
+
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].Lines() > merged[j].Lines() })
+	if len(merged) > maxLargest {
+		merged = merged[:maxLargest]
+	}
+	s.Largest = merged
+	return s
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RepoState holds the Items of a repository synced so far
+type RepoState struct {
+	// From is the time since which all updated Items are stored
+	From time.Time
+	// SyncedAt is the time of the last complete sync
+	SyncedAt time.Time
+	Items    Items
+}
+
+// State is a local store of the PRs and Issues of repositories. It is
+// updated incrementally with the Items updated since the last sync and
+// can be used to create reports without the GitHub API.
+type State struct {
+	// Version is the cacheVersion the State was written with. A
+	// State of another version is discarded.
+	Version int
+	Repos   map[string]*RepoState
+
+	path string
+}
+
+// LoadState reads the State from a file. If the file does not exist
+// an empty State is returned which is created by Save.
+func LoadState(path string) (*State, error) {
+	s := &State{
+		Version: cacheVersion,
+		Repos:   make(map[string]*RepoState),
+		path:    path,
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	loaded := &State{}
+	if err := json.Unmarshal(data, loaded); err != nil {
+		return nil, fmt.Errorf("corrupt state file %s: %v", path, err)
+	}
+	if loaded.Version != cacheVersion {
+		warnf("Ignoring state file %s of version %d\n", path, loaded.Version)
+		return s, nil
+	}
+	if loaded.Repos != nil {
+		s.Repos = loaded.Repos
+	}
+	return s, nil
+}
+
+// Save writes the State to its file
+func (s *State) Save() error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// Covers returns true if the State holds all Items of a repository
+// updated since a given time
+func (s *State) Covers(repo string, since time.Time) bool {
+	rs, ok := s.Repos[repo]
+	return ok && !rs.From.After(since)
+}
+
+// merge adds Items to the repository, replacing older versions of them
+func (rs *RepoState) merge(items Items) {
+	byNumber := make(map[int]int)
+	for n, i := range rs.Items {
+		byNumber[i.Number] = n
+	}
+	for _, i := range items {
+		if n, ok := byNumber[i.Number]; ok {
+			rs.Items[n] = i
+			continue
+		}
+		byNumber[i.Number] = len(rs.Items)
+		rs.Items = append(rs.Items, i)
+	}
+}
+
+// Sync fetches the Items of the repositories updated since their last
+// sync and adds them to the State. Repositories which are not in the
+// State or only hold Items updated after since are fetched from since.
+// A repository is only marked as synced if all its Items were fetched.
+func (f *Fetcher) Sync(ctx context.Context, s *State, repos []string, since time.Time, users *Users) {
+	// Fetch repositories synced at the same time together
+	groups := make(map[time.Time][]string)
+	var starts []time.Time
+	widen := make(map[string]bool)
+	for _, repo := range repos {
+		start := since
+		if s.Covers(repo, since) {
+			start = s.Repos[repo].SyncedAt
+		} else {
+			widen[repo] = true
+		}
+		if _, ok := groups[start]; !ok {
+			starts = append(starts, start)
+		}
+		groups[start] = append(groups[start], repo)
+	}
+
+	syncedAt := time.Now().UTC()
+	for _, start := range starts {
+		start := start
+		infof("Sync %v since %s\n", groups[start], start)
+		prs, issues := f.GetItems(ctx, groups[start], "all", &start, users)
+		for _, repo := range groups[start] {
+			rs, ok := s.Repos[repo]
+			if !ok {
+				rs = &RepoState{}
+				s.Repos[repo] = rs
+			}
+			var items Items
+			for _, i := range append(prs, issues...) {
+				if i.Repo == repo {
+					items = append(items, i)
+				}
+			}
+			rs.merge(items)
+		}
+	}
+
+	// Incomplete repositories are synced again from the last time and
+	// only cover since once they were fetched completely
+	incomplete := make(map[string]bool)
+	for _, fe := range f.Incomplete() {
+		incomplete[strings.SplitN(fe.ID, "#", 2)[0]] = true
+	}
+	for _, repo := range repos {
+		rs := s.Repos[repo]
+		if incomplete[repo] {
+			if rs.SyncedAt.IsZero() {
+				// Nothing is known to be complete
+				delete(s.Repos, repo)
+			}
+			continue
+		}
+		if widen[repo] {
+			rs.From = since
+		}
+		rs.SyncedAt = syncedAt
+	}
+}
+
+// Items returns the PRs and Issues of the repositories updated since a
+// given time, most recently updated first. All users of the Items are
+// added to users.
+func (s *State) Items(repos []string, since time.Time, users *Users) (Items, Items) {
+	var prs, issues Items
+	for _, repo := range repos {
+		rs, ok := s.Repos[repo]
+		if !ok {
+			continue
+		}
+		var items Items
+		for _, i := range rs.Items {
+			if allBefore(since, &i.CreatedAt, &i.UpdatedAt, &i.ClosedAt) {
+				continue
+			}
+			i.shareUsers(users)
+			items = append(items, i)
+		}
+		sort.SliceStable(items, func(i, j int) bool { return items[i].UpdatedAt.After(items[j].UpdatedAt) })
+		for _, i := range items {
+			if i.PR {
+				prs = append(prs, i)
+			} else {
+				issues = append(issues, i)
+			}
+		}
+	}
+	return prs, issues
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStateSync(t *testing.T) {
+	f := newFakeRepo()
+	defer f.Close()
+
+	dir, err := ioutil.TempDir("", "gh-report-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "state.json")
+
+	period, _ := NewPeriodFromMonth("2018-03")
+	state, err := LoadState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetcher := NewFetcher(f.Client(t), nil, 2)
+	users := make(Users)
+	fetcher.Sync(context.Background(), state, []string{"o/r"}, period.Start, &users)
+	if err := state.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if !state.Covers("o/r", period.Start) || state.Covers("o/r", period.Start.AddDate(0, -1, 0)) {
+		t.Errorf("state covers the wrong time since %s", state.Repos["o/r"].From)
+	}
+
+	// The second sync only lists the Items updated since the first one
+	synced := len(f.Requests())
+	state, err = LoadState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetcher.Sync(context.Background(), state, []string{"o/r"}, period.Start, &users)
+	for _, r := range f.Requests()[synced:] {
+		path := strings.SplitN(r, "?", 2)[0]
+		if !strings.HasSuffix(path, "/o/r/pulls") && !strings.HasSuffix(path, "/o/r/issues") {
+			t.Errorf("unexpected request: %s", r)
+		}
+		if strings.Contains(r, "since=2018") {
+			t.Errorf("Issues not listed since the last sync: %s", r)
+		}
+	}
+
+	// Reporting from the state needs no requests
+	requests := len(f.Requests())
+	users = make(Users)
+	prs, issues := state.Items([]string{"o/r"}, period.Start, &users)
+	var got []string
+	for _, i := range append(prs, issues...) {
+		got = append(got, i.ID)
+	}
+	if want := "o/r#5 o/r#3 o/r#1 o/r#4 o/r#2"; strings.Join(got, " ") != want {
+		t.Errorf("got %s, want %s", strings.Join(got, " "), want)
+	}
+	if users["bob"] != prs[2].MergedBy || users["bob"] != prs[2].Comments[0].User {
+		t.Errorf("users are not shared between items")
+	}
+	if len(f.Requests()) != requests {
+		t.Errorf("reporting from the state made requests")
+	}
+}
+
+func TestStateIncomplete(t *testing.T) {
+	defer fastRetries()()
+	f := newFakeRepo()
+	defer f.Close()
+	f.Fail("/repos/o/r/issues", http.StatusInternalServerError, -1)
+
+	period, _ := NewPeriodFromMonth("2018-03")
+	state, err := LoadState(filepath.Join("testdata", "does-not-exist.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetcher := NewFetcher(f.Client(t), nil, 2)
+	users := make(Users)
+	fetcher.Sync(context.Background(), state, []string{"o/r"}, period.Start, &users)
+	if state.Covers("o/r", period.Start) {
+		t.Errorf("incompletely fetched repository is marked as synced")
+	}
+}
+
+func TestStateIncompleteWiden(t *testing.T) {
+	defer fastRetries()()
+	f := newFakeRepo()
+	defer f.Close()
+
+	march, _ := NewPeriodFromMonth("2018-03")
+	january, _ := NewPeriodFromMonth("2018-01")
+	state, err := LoadState(filepath.Join("testdata", "does-not-exist.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetcher := NewFetcher(f.Client(t), nil, 2)
+	users := make(Users)
+	fetcher.Sync(context.Background(), state, []string{"o/r"}, march.Start, &users)
+	if !state.Covers("o/r", march.Start) {
+		t.Fatalf("repository is not synced")
+	}
+
+	// Widening the state fails, so it still only covers March
+	f.Fail("/repos/o/r/issues", http.StatusInternalServerError, -1)
+	fetcher = NewFetcher(f.Client(t), nil, 2)
+	fetcher.Sync(context.Background(), state, []string{"o/r"}, january.Start, &users)
+	if state.Covers("o/r", january.Start) || !state.Covers("o/r", march.Start) {
+		t.Errorf("state covers o/r since %s, want March", state.Repos["o/r"].From)
+	}
+
+	// and the next sync fetches from January again
+	f.Fail("/repos/o/r/issues", http.StatusInternalServerError, 0)
+	requests := len(f.Requests())
+	fetcher = NewFetcher(f.Client(t), nil, 2)
+	fetcher.Sync(context.Background(), state, []string{"o/r"}, january.Start, &users)
+	if !state.Covers("o/r", january.Start) {
+		t.Errorf("state covers o/r since %s, want January", state.Repos["o/r"].From)
+	}
+	var listed bool
+	for _, r := range f.Requests()[requests:] {
+		if strings.Contains(r, "/o/r/issues?") {
+			listed = true
+			if !strings.Contains(r, "since=2018-01-01") {
+				t.Errorf("Issues not listed since January: %s", r)
+			}
+		}
+	}
+	if !listed {
+		t.Errorf("Issues were not listed")
+	}
+}
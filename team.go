@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/github"
+)
+
+// GetTeamMembers returns the sorted logins of the members of a team
+// given as org/team-slug
+func (f *Fetcher) GetTeamMembers(ctx context.Context, team string) ([]string, error) {
+	t := strings.SplitN(team, "/", 2)
+	if len(t) != 2 || t[0] == "" || t[1] == "" {
+		return nil, fmt.Errorf("%s is malformed, expected org/team-slug", team)
+	}
+	org, slug := t[0], t[1]
+
+	// The API only looks up teams by ID, so find the ID by slug
+	var id int64
+	err := doListOp(func(page int) (*github.Response, error) {
+		teams, resp, err := f.Client.Organizations.ListTeams(ctx, org, &github.ListOptions{Page: page})
+		if err != nil {
+			return nil, err
+		}
+		for _, ghTeam := range teams {
+			if ghTeam.GetSlug() == slug {
+				id = ghTeam.GetID()
+			}
+		}
+		return resp, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if id == 0 {
+		return nil, fmt.Errorf("no team %s in %s", slug, org)
+	}
+
+	var members []string
+	err = doListOp(func(page int) (*github.Response, error) {
+		opts := &github.OrganizationListTeamMembersOptions{}
+		opts.ListOptions.Page = page
+		ghUsers, resp, err := f.Client.Organizations.ListTeamMembers(ctx, id, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range ghUsers {
+			members = append(members, u.GetLogin())
+		}
+		return resp, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(members)
+	return members, nil
+}
+
+// TeamTotals holds the number of distinct PRs and Issues the members
+// of a team worked on. An Item authored by one member and reviewed by
+// another is counted in both columns, but only once per column.
+type TeamTotals struct {
+	Members         int
+	PRs             int
+	ReviewedPRs     int
+	Issues          int
+	CommentedIssues int
+}
+
+// TeamReport is the computed report about activity of the members of
+// a team or of a list of users
+type TeamReport struct {
+	// BaseURL is the URL of the GitHub web interface
+	BaseURL string
+	Period  *Period
+	Repos   []string
+	// Team is empty for a list of users
+	Team   string
+	Totals TeamTotals
+	// Members holds a report per member
+	Members []*UserReport
+	// Users contains all users involved with the Items of the report
+	Users Users
+}
+
+// NewTeamReport computes a report about activity of the members of a
+// team. team is empty if members is an arbitrary list of users.
+func NewTeamReport(baseURL string, repos []string, period *Period, team string, members []string, allPRs, allIssues Items) *TeamReport {
+	r := &TeamReport{
+		BaseURL: baseURL,
+		Period:  period,
+		Repos:   repos,
+		Team:    team,
+		Members: []*UserReport{},
+		Users:   make(Users),
+	}
+
+	count := func(seen map[string]bool, items Items) {
+		for _, i := range items {
+			seen[i.ID] = true
+		}
+	}
+	prs := make(map[string]bool)
+	reviewed := make(map[string]bool)
+	issues := make(map[string]bool)
+	commented := make(map[string]bool)
+	for _, m := range members {
+		ur := NewUserReport(baseURL, repos, period, m, allPRs, allIssues)
+		r.Members = append(r.Members, ur)
+		count(prs, ur.PRs)
+		count(reviewed, ur.ReviewedPRs)
+		count(issues, ur.Issues)
+		count(commented, ur.CommentedIssues)
+	}
+	r.Totals = TeamTotals{
+		Members:         len(members),
+		PRs:             len(prs),
+		ReviewedPRs:     len(reviewed),
+		Issues:          len(issues),
+		CommentedIssues: len(commented),
+	}
+	for _, ur := range r.Members {
+		for id, u := range ur.Users {
+			r.Users[id] = u
+		}
+	}
+	return r
+}
+
+// Items returns all PRs and Issues of the report, each only once
+func (r *TeamReport) Items() Items {
+	var items Items
+	seen := make(map[string]bool)
+	for _, ur := range r.Members {
+		for _, list := range []Items{ur.PRs, ur.ReviewedPRs, ur.Issues, ur.CommentedIssues} {
+			for _, i := range list {
+				if !seen[i.ID] {
+					seen[i.ID] = true
+					items = append(items, i)
+				}
+			}
+		}
+	}
+	return items
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// templateFuncs are the helper functions available in all templates
+var templateFuncs = template.FuncMap{
+	"byRepo":    byRepo,
+	"date":      formatDate,
+	"delta":     delta,
+	"duration":  formatDuration,
+	"joinUsers": joinUsers,
+	"link":      markdownLink,
+	"user":      func(u *User) string { return u.String() },
+}
+
+// markdownTemplates render the reports as markdown. They are also the
+// base for user supplied templates.
+var markdownTemplates = template.Must(template.New("markdown").Funcs(templateFuncs).Parse(markdownSource))
+
+// LoadTemplate parses a user supplied template file. The template is
+// executed with the report as data and may use all the built-in
+// templates, e.g. {{template "groups" .MergedPRGroups}}.
+func LoadTemplate(path string) (*template.Template, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	t, err := markdownTemplates.Clone()
+	if err != nil {
+		return nil, err
+	}
+	return t.New(filepath.Base(path)).Parse(string(data))
+}
+
+// formatDate formats the date part of a time
+func formatDate(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+// markdownLink returns an inline markdown link
+func markdownLink(text, url string) string {
+	return fmt.Sprintf("[%s](%s)", text, url)
+}
+
+// joinUsers returns a list of users in the form "a, b and c"
+func joinUsers(users []*User) string {
+	var s []string
+	for _, u := range users {
+		s = append(s, u.String())
+	}
+	if len(s) < 2 {
+		return strings.Join(s, "")
+	}
+	return strings.Join(s[:len(s)-1], ", ") + " and " + s[len(s)-1]
+}
+
+const markdownSource = `
+{{- define "groups"}}{{range .}}
+### {{if .Label}}{{.Label}}{{else}}Other{{end}}
+{{.Items}}
+{{end}}{{end}}
+
+{{- define "summary-trend"}}{{$p := .Previous}}There were {{.Summary.Contributions}} ({{delta .Summary.Contributions $p.Contributions}}) contributions (PRs/Issues/Comments) from {{.Summary.Contributors}} ({{delta .Summary.Contributors $p.Contributors}}) individual contributors. {{.Summary.OpenedPRs}} ({{delta .Summary.OpenedPRs $p.OpenedPRs}}) new PRs were opened and {{.Summary.MergedPRs}} ({{delta .Summary.MergedPRs $p.MergedPRs}}) PRs were merged. {{.Summary.OpenedIssues}} ({{delta .Summary.OpenedIssues $p.OpenedIssues}}) new issues were opened and {{.Summary.ClosedIssues}} ({{delta .Summary.ClosedIssues $p.ClosedIssues}}) issues were closed. Changes are relative to {{(index .Trend 0).Period}}.{{end}}
+
+{{- define "repo-report"}}# Report for {{.Period}}
+
+This report covers the development in the{{range .Repos}} [{{.}}]{{end}} repositories. {{with .Previous}}{{template "summary-trend" $}}{{else}}There were {{.Summary.Contributions}} contributions (PRs/Issues/Comments) from {{.Summary.Contributors}} individual contributors. {{.Summary.OpenedPRs}} new PRs were opened and {{.Summary.MergedPRs}} PRs were merged. {{.Summary.OpenedIssues}} new issues were opened and {{.Summary.ClosedIssues}} issues were closed.{{end}}{{with .Commits}} {{.Count}} commits from {{.Authors}} distinct authors landed on the default branches.{{end}}
+{{if .Trend}}
+## Trend:
+
+| Period | Contributions | Contributors | New PRs | Merged PRs | New issues | Closed issues |
+|---|---|---|---|---|---|---|
+| {{.Period}} | {{.Summary.Contributions}} | {{.Summary.Contributors}} | {{.Summary.OpenedPRs}} | {{.Summary.MergedPRs}} | {{.Summary.OpenedIssues}} | {{.Summary.ClosedIssues}} |
+{{range .Trend}}| {{.Period}} | {{.Summary.Contributions}} | {{.Summary.Contributors}} | {{.Summary.OpenedPRs}} | {{.Summary.MergedPRs}} | {{.Summary.OpenedIssues}} | {{.Summary.ClosedIssues}} |
+{{end}}{{end}}
+## Metrics:
+
+Median / 90th percentile (number of PRs/Issues)
+
+| Repository | First response | Time to merge | Time to close | No response yet |
+|---|---|---|---|---|
+{{range .Metrics}}| {{if .Repo}}[{{.Repo}}]{{else}}All{{end}} | {{.FirstResponse}} | {{.TimeToMerge}} | {{.TimeToClose}} | {{.NoResponse}} |
+{{end}}
+{{with .Reviews}}{{if or .Reviewers .Merged}}## Reviews:
+
+Merged PRs went through {{printf "%.1f" .AvgRounds}} review rounds on average.
+
+| Reviewer | Approvals | Changes requested | Comments | Line comments |
+|---|---|---|---|---|
+{{range .Reviewers}}| {{user .User}} | {{.Approvals}} | {{.ChangesRequested}} | {{.Comments}} | {{.LineComments}} |
+{{end}}{{if .Unapproved}}
+### Merged without approval:
+{{.Unapproved}}
+{{end}}
+{{end}}{{end}}{{with .Sizes}}{{if .Merged}}## PR sizes:
+
+The merged PRs added {{.Additions}} and removed {{.Deletions}} lines.
+
+| Size | Lines changed | PRs |
+|---|---|---|
+{{range .Buckets}}| {{.Label}} | {{.Lines}} | {{.PRs}} |
+{{end}}
+### Largest merged PRs:
+
+{{range .Largest}}- {{.Title}} ([{{.ID}}] {{user .CreatedBy}}) +{{.Additions}} -{{.Deletions}} in {{.ChangedFiles}} files
+{{end}}
+{{end}}{{end}}{{if .LabelCounts}}## Labels:
+
+| Label | Opened | Closed |
+|---|---|---|
+{{range .LabelCounts}}| {{.Label}} | {{.Opened}} | {{.Closed}} |
+{{end}}
+{{end}}## Merged PRs:
+{{if .GroupLabel}}{{template "groups" .MergedPRGroups}}{{else}}{{.MergedPRs}}
+{{end}}
+## Closed Issues:
+{{if .GroupLabel}}{{template "groups" .ClosedIssueGroups}}{{else}}{{.ClosedIssues}}
+{{end}}
+## New or updated PRs and Issues (not closed):
+{{.UpdatedItems}}
+{{with .Commits}}{{if .Direct}}
+## Commits without a PR:
+{{.Direct}}
+{{end}}{{end}}{{if .Newcomers}}
+## Welcome:
+
+A warm welcome to our new contributors:
+
+{{range .Newcomers}}- {{user .User}} {{if eq .Kind "comment"}}commented on{{else}}opened the {{.Kind}}{{end}} [{{.First.ID}}] on {{date .At}}
+{{end}}{{end}}{{if .NewcomersUnchecked}}
+Earlier activity could not be checked for {{joinUsers .NewcomersUnchecked}}, who may be new as well.
+{{end}}{{with .Backlog}}
+## Backlog:
+
+At the start of the period {{.OpenPRsAtStart}} PRs and {{.OpenIssuesAtStart}} issues were open, at the end {{.OpenPRsAtEnd}} PRs and {{.OpenIssuesAtEnd}} issues.
+
+| Age | PRs | Issues |
+|---|---|---|
+{{range .Ages}}| {{.Label}} | {{.PRs}} | {{.Issues}} |
+{{end}}
+### No activity for more than {{.StaleDays}} days:
+{{.Stale}}
+
+### Oldest unanswered:
+
+{{range .Unanswered}}- {{.Title}} ([{{.ID}}] {{user .CreatedBy}}) opened {{date .CreatedAt}}
+{{end}}{{end}}{{if .BotActivity}}
+## Bot activity:
+
+| User | PRs/Issues opened | PRs merged | Comments |
+|---|---|---|---|
+{{range .BotActivity}}| {{user .User}} | {{.Opened}} | {{.Merged}} | {{.Comments}} |
+{{end}}{{.BotItems}}
+{{end}}
+{{.Links}}
+{{end}}
+
+{{- define "user-summary-header"}}| User | PRs opened | PRs merged | Reviews given | Review comments | Issues opened | Issues commented |
+|---|---|---|---|---|---|---|
+{{end}}
+
+{{- define "user-summary-row"}}| @{{.User}} | {{.Summary.OpenedPRs}} | {{.Summary.MergedPRs}} | {{.Summary.Reviews}} | {{.Summary.ReviewComments}} | {{.Summary.OpenedIssues}} | {{.Summary.CommentedIssues}} |
+{{end}}
+
+{{- define "user-report"}}## Summary:
+
+{{template "user-summary-header"}}{{template "user-summary-row" .}}
+## PRs:
+{{.PRs}}
+
+## Reviewed PRs:
+{{.ReviewedPRs}}
+
+## Issues:
+{{.Issues}}
+
+## Issues commented on:
+{{.CommentedIssues}}
+
+{{.Links}}
+{{end}}
+
+{{- define "team-report"}}# Report for {{if .Team}}team {{.Team}}{{else}}{{range $n, $m := .Members}}{{if $n}}, {{end}}@{{.User}}{{end}}{{end}} from {{.Period}}
+
+## {{if .Team}}Team totals{{else}}Totals{{end}}:
+
+{{if .Team}}The {{.Totals.Members}} members of the team{{else}}The {{.Totals.Members}} users{{end}} opened {{.Totals.PRs}} PRs and {{.Totals.Issues}} issues, reviewed {{.Totals.ReviewedPRs}} PRs and commented on {{.Totals.CommentedIssues}} issues.
+
+## Summary:
+
+{{template "user-summary-header"}}{{range .Members}}{{template "user-summary-row" .}}{{end}}{{range .Members}}
+## @{{.User}}
+
+### PRs:
+{{.PRs}}
+
+### Reviewed PRs:
+{{.ReviewedPRs}}
+
+### Issues:
+{{.Issues}}
+
+### Issues commented on:
+{{.CommentedIssues}}
+{{end}}
+{{.Items.Links}}
+{{.Users.Links}}
+{{end}}
+
+{{- define "changelog"}}# Changes in [{{.Repo}}] from {{.From}} to {{.To}}
+{{range .Categories}}
+## {{.Label}}:
+
+{{range .Items}}- {{.Title}} ([{{.ID}}] {{user .CreatedBy}})
+{{end}}{{end}}
+{{- if .Contributors}}
+## Contributors:
+
+Thanks to {{joinUsers .Contributors}} for their contributions.
+{{end}}
+{{- if .FirstTimers}}
+A special welcome to our first-time contributors:
+
+{{range .FirstTimers}}- {{user .User}} with [{{.Item.ID}}]
+{{end}}{{end}}
+{{- if .FirstTimersUnchecked}}
+Earlier PRs could not be checked for {{joinUsers .FirstTimersUnchecked}}, who may be first-time contributors as well.
+{{end}}
+[{{.Repo}}]: {{.BaseURL}}{{.Repo}}
+{{.Items.Links}}
+{{.Users.Links}}
+{{end}}
+`
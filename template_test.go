@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadTemplate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gh-report-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "my.tmpl")
+	// The example of the README using the built-in groups template
+	src := `Week {{.Period}}: {{.Summary.MergedPRs}} PRs merged
+{{range .MergedPRs}}* {{link .ID .URL}} by {{user .CreatedBy}} on {{date .MergedAt}}
+{{end}}{{template "groups" .MergedPRGroups}}`
+	if err := ioutil.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl, err := LoadTemplate(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, _ := NewPeriodFromMonth("2018-03")
+	prs, issues := testItems()
+	r := NewRepoReport("https://github.com/", []string{"o/r"}, p, prs, issues)
+	r.GroupByLabel("kind/")
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	want := `Week 2018-03-01 to 2018-03-31: 1 PRs merged
+* [o/r#1]() by [@alice] on 2018-03-05
+
+### Other
+
+-  ([o/r#1] [@alice] [@bob])
+`
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
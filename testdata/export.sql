@@ -0,0 +1,90 @@
+BEGIN TRANSACTION;
+CREATE TABLE IF NOT EXISTS repos (
+	name TEXT PRIMARY KEY,        -- owner/repo
+	exported_at TEXT NOT NULL     -- last export of the repository
+);
+CREATE TABLE IF NOT EXISTS users (
+	id TEXT PRIMARY KEY,          -- GitHub login
+	url TEXT,
+	avatar_url TEXT,
+	bot INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS items (
+	id TEXT PRIMARY KEY,          -- owner/repo#number
+	repo TEXT NOT NULL REFERENCES repos(name),
+	number INTEGER NOT NULL,
+	pr INTEGER NOT NULL,          -- 1 for PRs, 0 for Issues
+	state TEXT NOT NULL,          -- open or closed
+	title TEXT NOT NULL,
+	url TEXT NOT NULL,
+	created_by TEXT REFERENCES users(id),
+	author_association TEXT,      -- e.g. MEMBER or CONTRIBUTOR
+	created_at TEXT NOT NULL,
+	updated_at TEXT NOT NULL,
+	closed_at TEXT,
+	merged INTEGER NOT NULL,
+	merged_at TEXT,
+	merged_by TEXT REFERENCES users(id),
+	merge_commit_sha TEXT,
+	additions INTEGER NOT NULL,
+	deletions INTEGER NOT NULL,
+	changed_files INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS labels (
+	item_id TEXT NOT NULL REFERENCES items(id),
+	name TEXT NOT NULL,
+	PRIMARY KEY (item_id, name)
+);
+CREATE TABLE IF NOT EXISTS comments (
+	item_id TEXT NOT NULL REFERENCES items(id),
+	seq INTEGER NOT NULL,
+	kind TEXT NOT NULL,           -- comment or review_comment
+	user TEXT REFERENCES users(id),
+	created_at TEXT NOT NULL,
+	PRIMARY KEY (item_id, seq)
+);
+CREATE TABLE IF NOT EXISTS reviews (
+	item_id TEXT NOT NULL REFERENCES items(id),
+	seq INTEGER NOT NULL,
+	state TEXT NOT NULL,          -- APPROVED, CHANGES_REQUESTED, COMMENTED or DISMISSED
+	user TEXT REFERENCES users(id),
+	submitted_at TEXT NOT NULL,
+	PRIMARY KEY (item_id, seq)
+);
+INSERT OR REPLACE INTO users VALUES ('alice', 'https://github.com/alice', NULL, 0);
+INSERT OR REPLACE INTO users VALUES ('bob', 'https://github.com/bob', NULL, 0);
+INSERT OR REPLACE INTO users VALUES ('carol', 'https://github.com/carol', NULL, 0);
+INSERT OR REPLACE INTO users VALUES ('dave', 'https://github.com/dave', NULL, 0);
+INSERT OR REPLACE INTO users VALUES ('erin', 'https://github.com/erin', NULL, 0);
+INSERT OR REPLACE INTO repos VALUES ('o/r', '2018-04-01T12:00:00Z');
+INSERT OR REPLACE INTO items VALUES ('o/r#5', 'o/r', 5, 1, 'closed', 'Rework everything', 'https://github.com/o/r/pull/5', 'alice', NULL, '2018-01-10T10:00:00Z', '2018-03-20T10:00:00Z', '2018-03-20T10:00:00Z', 0, NULL, NULL, NULL, 50, 5, 1);
+DELETE FROM labels WHERE item_id = 'o/r#5';
+DELETE FROM comments WHERE item_id = 'o/r#5';
+DELETE FROM reviews WHERE item_id = 'o/r#5';
+INSERT INTO reviews VALUES ('o/r#5', 1, 'COMMENTED', 'dave', '2018-03-15T10:00:00Z');
+INSERT OR REPLACE INTO items VALUES ('o/r#3', 'o/r', 3, 1, 'open', 'Don''t break quoting', 'https://github.com/o/r/pull/3', 'carol', NULL, '2018-03-10T10:00:00Z', '2018-03-10T10:00:00Z', NULL, 0, NULL, NULL, NULL, 30, 3, 1);
+DELETE FROM labels WHERE item_id = 'o/r#3';
+DELETE FROM comments WHERE item_id = 'o/r#3';
+DELETE FROM reviews WHERE item_id = 'o/r#3';
+INSERT OR REPLACE INTO labels VALUES ('o/r#3', 'kind/bug');
+INSERT INTO comments VALUES ('o/r#3', 1, 'comment', 'erin', '2018-03-10T12:00:00Z');
+INSERT OR REPLACE INTO items VALUES ('o/r#1', 'o/r', 1, 1, 'closed', 'Add feature', 'https://github.com/o/r/pull/1', 'alice', NULL, '2018-03-02T10:00:00Z', '2018-03-05T10:00:00Z', '2018-03-05T10:00:00Z', 1, '2018-03-05T10:00:00Z', 'bob', 'abc', 10, 1, 1);
+DELETE FROM labels WHERE item_id = 'o/r#1';
+DELETE FROM comments WHERE item_id = 'o/r#1';
+DELETE FROM reviews WHERE item_id = 'o/r#1';
+INSERT OR REPLACE INTO labels VALUES ('o/r#1', 'kind/feature');
+INSERT INTO comments VALUES ('o/r#1', 1, 'review_comment', 'bob', '2018-03-03T10:00:00Z');
+INSERT INTO reviews VALUES ('o/r#1', 1, 'CHANGES_REQUESTED', 'bob', '2018-03-03T10:00:00Z');
+INSERT INTO reviews VALUES ('o/r#1', 2, 'COMMENTED', 'alice', '2018-03-03T12:00:00Z');
+INSERT INTO reviews VALUES ('o/r#1', 3, 'APPROVED', 'bob', '2018-03-04T10:00:00Z');
+INSERT OR REPLACE INTO items VALUES ('o/r#4', 'o/r', 4, 0, 'open', 'Question', 'https://github.com/o/r/issues/4', 'erin', NULL, '2018-03-12T10:00:00Z', '2018-03-12T10:00:00Z', NULL, 0, NULL, NULL, NULL, 0, 0, 0);
+DELETE FROM labels WHERE item_id = 'o/r#4';
+DELETE FROM comments WHERE item_id = 'o/r#4';
+DELETE FROM reviews WHERE item_id = 'o/r#4';
+INSERT OR REPLACE INTO items VALUES ('o/r#2', 'o/r', 2, 0, 'closed', 'Crash on start', 'https://github.com/o/r/issues/2', 'dave', NULL, '2018-03-04T10:00:00Z', '2018-03-08T10:00:00Z', '2018-03-08T10:00:00Z', 0, NULL, NULL, NULL, 0, 0, 0);
+DELETE FROM labels WHERE item_id = 'o/r#2';
+DELETE FROM comments WHERE item_id = 'o/r#2';
+DELETE FROM reviews WHERE item_id = 'o/r#2';
+INSERT OR REPLACE INTO labels VALUES ('o/r#2', 'kind/bug');
+INSERT INTO comments VALUES ('o/r#2', 1, 'comment', 'alice', '2018-03-05T10:00:00Z');
+COMMIT;
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+)
+
+// TrendEntry holds the counters of a period preceding the report
+type TrendEntry struct {
+	Period  *Period
+	Summary Summary
+}
+
+// PreviousPeriods returns the n periods before p, most recent first
+func PreviousPeriods(p *Period, n int) []*Period {
+	var periods []*Period
+	for i := 0; i < n; i++ {
+		p = p.Previous()
+		periods = append(periods, p)
+	}
+	return periods
+}
+
+// Compare computes the counters for the preceding periods from the
+// same PRs and Issues the report was computed from. They must have been
+// fetched since the start of the earliest period.
+func (r *RepoReport) Compare(periods []*Period, allPRs, allIssues Items) {
+	r.Trend = nil
+	for _, p := range periods {
+		prev := NewRepoReport(r.BaseURL, r.Repos, p, allPRs, allIssues)
+		r.Trend = append(r.Trend, &TrendEntry{Period: p, Summary: prev.Summary})
+	}
+}
+
+// Previous returns the counters of the preceding period or nil if the
+// report was not compared to other periods
+func (r *RepoReport) Previous() *Summary {
+	if len(r.Trend) == 0 {
+		return nil
+	}
+	return &r.Trend[0].Summary
+}
+
+// delta returns the change from prev to cur as an absolute number and
+// a percentage, e.g. "+5, +12%"
+func delta(cur, prev int) string {
+	if prev == 0 {
+		return fmt.Sprintf("%+d", cur-prev)
+	}
+	return fmt.Sprintf("%+d, %+.0f%%", cur-prev, float64(cur-prev)*100/float64(prev))
+}